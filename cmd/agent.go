@@ -0,0 +1,54 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var agentCmdLong = `Run an agent that holds encryption keys derived from
+passphrases in locked memory, similar to ssh-agent. Commands decrypting
+or encrypting secrets consult the agent via MKSECRET_AUTH_SOCK env. var
+before prompting for passphrase. Keys never leave the agent and are
+forgotten when agent is idle for longer than timeout.
+
+Agent runs in foreground and prints shell commands to set env. var`
+
+// agentCmd represents the agent command
+var agentCmd = &cobra.Command{
+	Use:     "agent",
+	Short:   "Run passphrase caching agent",
+	Long:    agentCmdLong,
+	RunE:    run.Agent,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s agent > agent.env &", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	f := agentCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Socket), "", "Unix socket path (default is in a new private temp dir)")
+	f.Duration(b(flags.Timeout), 15*time.Minute, "Forget keys when idle for this long (0 to disable)")
+}
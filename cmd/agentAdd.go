@@ -0,0 +1,41 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentAddCmd represents the agent add command
+var agentAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add passphrase to the agent",
+	Long: `Derive encryption key from a passphrase and add it to the agent.
+Most recently added key is used for encrypting new secrets while all
+keys are tried for decrypting`,
+	RunE: run.AgentAdd,
+	Args: cobra.ExactArgs(0),
+}
+
+func init() {
+	agentCmd.AddCommand(agentAddCmd)
+	f := agentAddCmd.Flags()
+
+	f.String(flags.Passphrase, "", "Encryption passphrase")
+	addPassphraseSourceFlags(f)
+}
@@ -0,0 +1,34 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentForgetCmd represents the agent forget command
+var agentForgetCmd = &cobra.Command{
+	Use:   "forget",
+	Short: "Remove all keys from the agent",
+	Long:  `Remove all keys from the agent wiping them from memory`,
+	RunE:  run.AgentForget,
+	Args:  cobra.ExactArgs(0),
+}
+
+func init() {
+	agentCmd.AddCommand(agentForgetCmd)
+}
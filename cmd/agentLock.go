@@ -0,0 +1,34 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// agentLockCmd represents the agent lock command
+var agentLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the agent",
+	Long:  `Lock the agent using a password so that keys cannot be used until unlocked`,
+	RunE:  run.AgentLock,
+	Args:  cobra.ExactArgs(0),
+}
+
+func init() {
+	agentCmd.AddCommand(agentLockCmd)
+}
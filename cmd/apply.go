@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var applyCmdLong = `Create, update and optionally prune secrets to match
+a secrets manifest. See plan command for the manifest format.
+
+Payloads are never accessed unless a value source is declared for
+a secret, in which case a new version is added only when the value
+differs from the latest version. Immutable properties such as
+replication and encryption are never changed and result in an error.
+
+Secrets deleted by prune are listed and need to be confirmed
+unless --force is set`
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:     "apply",
+	Short:   "Apply a secrets manifest",
+	Long:    applyCmdLong,
+	RunE:    run.Apply,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s apply -f secrets.yaml --prune", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	f := applyCmd.Flags()
+	b := filepath.Base
+
+	f.StringP(b(flags.File), "f", "", "Secrets manifest file")
+	f.Bool(b(flags.Prune), false, "Delete secrets not declared in the manifest")
+	f.Bool(b(flags.Force), false, "Force prune without asking confirmation")
+	f.String(flags.Passphrase, "", "Encryption passphrase for declared values")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
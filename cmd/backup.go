@@ -0,0 +1,57 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var backupCmdLong = `Export all secrets managed by this app, with all their
+enabled versions, labels and metadata, into a single encrypted bundle.
+
+Payloads are stored exactly as they are in secret manager, so secrets
+encrypted by this app remain encrypted with their own passphrase.
+The bundle is encrypted with a passphrase or, when --recipient flags
+are provided, for recipients generated using backup keygen command`
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:     "backup",
+	Short:   "Backup all managed secrets into an encrypted bundle",
+	Long:    backupCmdLong,
+	RunE:    run.Backup,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s backup --out bundle.msb", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	f := backupCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Out), "", "Output bundle file")
+	f.StringP(b(flags.Selector), "l", "", "Label selector to filter secrets (e.g. key1=value1,key2!=value2)")
+	f.StringSlice(b(flags.Recipient), nil, "Recipient to encrypt the bundle for")
+	f.String(flags.Passphrase, "", "Bundle encryption passphrase")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
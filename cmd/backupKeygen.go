@@ -0,0 +1,51 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var backupKeygenCmdLong = `Generate an identity for recipient based bundle encryption.
+The recipient is used with backup --recipient flag and the identity
+is used with restore --identity flag.
+
+When --out is provided, identity is written to the file with 0600
+permissions and only the recipient is printed`
+
+// backupKeygenCmd represents the backup keygen command
+var backupKeygenCmd = &cobra.Command{
+	Use:     "keygen",
+	Short:   "Generate a bundle encryption identity",
+	Long:    backupKeygenCmdLong,
+	RunE:    run.BackupKeygen,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s backup keygen --out identity.key", app.Name),
+}
+
+func init() {
+	backupCmd.AddCommand(backupKeygenCmd)
+	f := backupKeygenCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Out), "", "Output identity file")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var certsExpiringCmdLong = `List secrets of type tls whose certificates expire
+within requested duration, including already expired certificates.
+Expiry is read from labels recorded when certificates are set, hence
+secret payloads are not accessed`
+
+// certsExpiringCmd represents the certs expiring command
+var certsExpiringCmd = &cobra.Command{
+	Use:     "expiring",
+	Short:   "List expiring tls certificates",
+	Long:    certsExpiringCmdLong,
+	RunE:    run.CertsExpiring,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s certs expiring --within 30d", app.Name),
+}
+
+func init() {
+	certsCmd.AddCommand(certsExpiringCmd)
+	f := certsExpiringCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Within), "30d", "Duration within which certificates expire (e.g. 30d or 720h)")
+	f.StringP(b(flags.Selector), "l", "", "Label selector to filter secrets (e.g. key1=value1,key2!=value2)")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCompleteSecretNameShowsLastUpdated(t *testing.T) {
+	const secretName = "projects/test-project/secrets/rotated-token"
+
+	fake.addSecret(
+		secretName,
+		map[string]string{app.KeyManagedBy: app.Name, "env": "prod"},
+		[]byte("v1"), []byte("v2"),
+	)
+
+	created := time.Date(2020, 1, 2, 3, 4, 0, 0, time.Local)
+	updated := time.Date(2021, 5, 6, 7, 8, 0, 0, time.Local)
+
+	fake.mu.Lock()
+	fake.secrets[secretName].CreateTime = timestamppb.New(created)
+	fake.versions[secretName][0].version.CreateTime = timestamppb.New(created)
+	fake.versions[secretName][1].version.CreateTime = timestamppb.New(updated)
+	fake.mu.Unlock()
+
+	stdout, _, err := execute(
+		[]string{cobra.ShellCompRequestCmd, "get", "--google-project-id=test-project", "rotated"},
+	)
+	if err != nil {
+		t.Fatalf("completion failed: %v", err)
+	}
+
+	want := "rotated-token\tenv=prod updated 2021-05-06 07:08"
+	if !strings.Contains(stdout, want) {
+		t.Errorf("completion output %q does not contain %q", stdout, want)
+	}
+}
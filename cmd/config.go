@@ -0,0 +1,63 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/config"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var configCmdLong = `Manage contexts in config file. A context is a named profile
+of settings such as project, credentials, backend, encryption,
+replication and output format. Settings of the current context,
+or of the context from --context flag, override top level settings
+of the config file, while flags and env. vars take precedence`
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage config contexts",
+	Long:  configCmdLong,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
+
+// completeContextName completes context names from config file
+func completeContextName(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) (
+	[]string,
+	cobra.ShellCompDirective,
+) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return contextNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// contextNames lists names of contexts in config file
+func contextNames() []string {
+	contexts := make(map[string]config.Context)
+	_ = viper.UnmarshalKey(flags.Contexts, &contexts)
+	return config.SortedNames(contexts)
+}
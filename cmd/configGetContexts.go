@@ -0,0 +1,38 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// configGetContextsCmd represents the config get-contexts command
+var configGetContextsCmd = &cobra.Command{
+	Use:     "get-contexts",
+	Short:   "List contexts",
+	Long:    `List contexts in config file marking the context in use`,
+	RunE:    run.ConfigGetContexts,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s config get-contexts --output-format=table", app.Name),
+}
+
+func init() {
+	configCmd.AddCommand(configGetContextsCmd)
+}
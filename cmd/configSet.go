@@ -0,0 +1,58 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/config"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var configSetCmdLong = `Set a property in config file. Property is either
+current-context, a key of a named context such as
+contexts.prod.google-project-id, which creates the context if it
+does not exist, or a key of the context in use.
+
+Valid keys are: keyList
+Replication is a comma separated list of locations. An empty value
+removes the key. Config file is updated atomically preserving
+comments and other settings`
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:   "set PROPERTY [VALUE]",
+	Short: "Set a property in config file",
+	Long: strings.ReplaceAll(
+		configSetCmdLong,
+		"keyList",
+		strings.Join(config.Keys, ", "),
+	),
+	RunE: run.ConfigSet,
+	Args: cobra.RangeArgs(1, 2),
+	Example: fmt.Sprintf(
+		"%s config set contexts.prod.google-project-id my-prod-project\n%s config set replication us-east1,us-west1 --context prod",
+		app.Name,
+		app.Name,
+	),
+}
+
+func init() {
+	configCmd.AddCommand(configSetCmd)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// configUseContextCmd represents the config use-context command
+var configUseContextCmd = &cobra.Command{
+	Use:               "use-context NAME",
+	Short:             "Set current context",
+	Long:              `Set current context in config file. Context must exist`,
+	RunE:              run.ConfigUseContext,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextName,
+	Example:           fmt.Sprintf("%s config use-context prod", app.Name),
+}
+
+func init() {
+	configCmd.AddCommand(configUseContextCmd)
+}
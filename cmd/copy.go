@@ -0,0 +1,63 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var copyCmdLong = `Copy a secret to another project and/or name preserving its
+labels and metadata. Payloads are copied byte-for-byte, so secrets
+encrypted by this app remain encrypted with the same passphrase.
+
+Only the latest enabled version is copied unless --all-versions is set,
+in which case enabled versions missing in the destination secret are
+copied in order. Destination versions need to match the first source
+versions, else the copy is reported as a conflict.
+
+Topics are project resources and are copied to another project only
+when --copy-topics is set. Rotation is dropped when topics are not
+copied, whereas expiration and rotation times in the past are dropped
+and moved forward respectively`
+
+// copyCmd represents the copy command
+var copyCmd = &cobra.Command{
+	Use:               "copy NAME",
+	Short:             "Copy a secret to another project",
+	Long:              copyCmdLong,
+	RunE:              run.Copy,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s copy db-password --to-project prod-project --all-versions", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+	f := copyCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.ToProject), "", "Destination project (default is source project)")
+	f.String(b(flags.ToName), "", "Destination secret name (default is source name)")
+	f.Bool(b(flags.AllVersions), false, "Copy all enabled versions")
+	f.Bool(b(flags.CopyTopics), false, "Copy Pub/Sub topics to destination project")
+	f.Bool(b(flags.DryRun), false, "Show changes without making them")
+}
@@ -25,11 +25,12 @@ import (
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
-	Short: "Delete phrase",
-	Long:  `Delete a named phrase`,
-	RunE:  run.Delete,
-	Args:  cobra.ExactArgs(1),
+	Use:               "delete [NAME]",
+	Short:             "Delete phrase",
+	Long:              `Delete a named phrase`,
+	RunE:              run.Delete,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSecretName,
 }
 
 func init() {
@@ -0,0 +1,60 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/bip32/pkg/keys"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var deriveCmdLong = `Derive a BIP32 child key from a stored mnemonic, extended
+private key or hex encoded seed. Secret is decrypted if required.
+
+Derived key is only written to output, unless --store-as is provided,
+in which case its extended private key is stored as a new version of
+that secret, encrypted using same passphrase as the source secret`
+
+// deriveCmd represents the derive command
+var deriveCmd = &cobra.Command{
+	Use:               "derive NAME",
+	Short:             "Derive BIP32 key from a stored mnemonic or seed",
+	Long:              deriveCmdLong,
+	RunE:              run.Derive,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s derive my-mnemonic-1 --path=\"m/44'/60'/0'/0/0\"", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(deriveCmd)
+	f := deriveCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.DerivationPath), "m", "Derivation path")
+	f.String(b(flags.Network), keys.NetworkTypeMainnet, "Network type (mainnet or testnet)")
+	f.String(b(flags.Bip39Passphrase), "", "BIP39 passphrase for mnemonic secrets")
+	f.String(b(flags.StoreAs), "", "Store derived extended private key as named secret")
+	f.String(b(flags.Version), "latest", "Secret version")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
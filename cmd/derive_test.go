@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+)
+
+func TestDeriveStoreAsEnforcesPassphrasePolicy(t *testing.T) {
+	const mnemonic = "abandon abandon abandon abandon abandon abandon " +
+		"abandon abandon abandon abandon abandon about"
+
+	fake.addSecret(
+		"projects/test-project/secrets/wallet",
+		map[string]string{app.KeyManagedBy: app.Name, app.KeyType: app.TypeMnemonic},
+		[]byte(mnemonic),
+	)
+	fake.addSecret(
+		"projects/test-project/secrets/wallet-key",
+		map[string]string{app.KeyManagedBy: app.Name, app.KeyEncrypted: app.ValueTrue},
+	)
+
+	_, _, err := execute(
+		[]string{
+			"derive", "wallet", "--store-as=wallet-key",
+			"--google-project-id=test-project", "--output-format=json",
+		},
+		"password", "password",
+	)
+	if err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Fatalf("expected passphrase policy error, got %v", err)
+	}
+
+	if payloads := fake.payloads("projects/test-project/secrets/wallet-key"); len(payloads) > 0 {
+		t.Fatalf("derived key was stored with a weak passphrase")
+	}
+}
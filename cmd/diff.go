@@ -0,0 +1,55 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var diffCmdLong = `Show what changed between two versions of a secret.
+Versions are decrypted if required and compared field by field when
+both are JSON documents, else a unified diff is shown.
+
+Use --redacted to only show whether versions differ along with
+SHA-256 hashes of their values`
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:               "diff NAME VERSION1 VERSION2",
+	Short:             "Diff two versions of a secret",
+	Long:              diffCmdLong,
+	RunE:              run.Diff,
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s diff db-config 1 latest", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	f := diffCmd.Flags()
+	b := filepath.Base
+
+	f.Bool(b(flags.Redacted), false, "Only show whether versions differ along with hashes")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
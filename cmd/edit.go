@@ -0,0 +1,54 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var editCmdLong = `Edit latest version of a secret in an editor.
+Value is decrypted if required into a file in a private temp dir,
+preferably on tmpfs, and opened using $VISUAL or $EDITOR.
+
+A new version is added, encrypted the same way as the latest version,
+only if the value was modified. JSON values and values of tls and
+ssh-key secrets are validated before saving. Temp files are shredded
+once the editor exits`
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:               "edit [NAME]",
+	Short:             "Edit a secret in an editor",
+	Long:              editCmdLong,
+	RunE:              run.Edit,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("EDITOR=nano %s edit db-config", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	f := editCmd.Flags()
+
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec -- command [args...]",
+	Short: "Run a command with secrets in its environment",
+	Long: `Resolve secrets and run a command with their values injected
+as environment variables. Each --env flag maps an env. var to a secret
+name and an optional version in the form KEY=name[@version].
+
+Signals are forwarded to the command and its exit code is returned.
+Secret values are never written to the output`,
+	RunE: run.Exec,
+	Args: cobra.MinimumNArgs(1),
+	Example: fmt.Sprintf(
+		"%s exec --env DB_PASS=db-password --env API_KEY=api-key@3 -- ./server",
+		app.Name,
+	),
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	f := execCmd.Flags()
+	b := filepath.Base
+
+	// stop parsing flags at the first positional arg so that
+	// flags of the command being executed are passed through
+	f.SetInterspersed(false)
+
+	f.StringSlice(b(flags.Env), nil, "Env. var mapping in the form KEY=name[@version]")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
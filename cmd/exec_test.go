@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/run"
+)
+
+func TestExecSignaledExitCode(t *testing.T) {
+	_, _, err := execute(
+		[]string{
+			"exec", "--google-project-id=test-project",
+			"--", "sh", "-c", "kill -TERM $$",
+		},
+	)
+
+	var exitErr *run.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected exit error, got %v", err)
+	}
+
+	// SIGTERM is 15
+	if exitErr.Code != 128+15 {
+		t.Errorf("expected exit code %d, got %d", 128+15, exitErr.Code)
+	}
+}
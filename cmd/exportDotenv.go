@@ -0,0 +1,53 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var exportDotenvCmdLong = `Export latest versions of secrets as a dotenv file.
+Secret names are converted to keys, for instance db-password is
+written as DB_PASSWORD, and all values are double quoted.
+Encrypted secrets are decrypted using the passphrase`
+
+// exportDotenvCmd represents the export dotenv command
+var exportDotenvCmd = &cobra.Command{
+	Use:     "dotenv",
+	Short:   "Export secrets as a dotenv file",
+	Long:    exportDotenvCmdLong,
+	RunE:    run.ExportDotenv,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s export dotenv --selector env=dev --out .env", app.Name),
+}
+
+func init() {
+	exportCmd.AddCommand(exportDotenvCmd)
+	f := exportDotenvCmd.Flags()
+	b := filepath.Base
+
+	f.StringP(b(flags.Selector), "l", "", "Label selector to filter secrets (e.g. key1=value1,key2!=value2)")
+	f.String(b(flags.Out), "", "Output file (default is STDOUT)")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
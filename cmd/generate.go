@@ -0,0 +1,62 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/generate"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var generateCmdLong = `Generate a random secret value and write it as a new version
+to the named secret. Supported kinds of values are passwords drawn
+from character classes, hex, base64 and base58 encoded random bytes,
+UUIDs and diceware passphrases using BIP39 english word list.
+
+Generated value is encrypted if requested, same as with set command`
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:     "generate",
+	Short:   "Generate a random secret as a new version",
+	Long:    generateCmdLong,
+	RunE:    run.Generate,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s generate --name=db-password --length=24 --exclude-ambiguous", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	f := generateCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Name), "", "Name tag for the secret, DNS1123 labels optionally in namespaces such as team/app/name")
+	f.Bool(b(flags.Encrypt), false, "Turn on encryption (true when passphrase is provided)")
+	f.String(flags.Passphrase, "", "Encryption passphrase")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+	f.String(b(flags.Kind), generate.KindPassword, fmt.Sprintf("Kind of generated value %v", generate.Kinds()))
+	f.Int(b(flags.Length), 0, "Length of generated password or number of random bytes of generated token")
+	f.StringSlice(b(flags.Charset), generate.Classes(), "Character classes of generated password")
+	f.Bool(b(flags.ExcludeAmbiguous), false, "Exclude ambiguous characters from generated password")
+	f.Int(b(flags.Words), generate.DefaultWords, "Number of words of generated diceware passphrase")
+	f.String(b(flags.Separator), generate.DefaultSeparator, "Separator of generated diceware passphrase words")
+}
@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/kubetrail/mksecret/pkg/flags"
 	"github.com/kubetrail/mksecret/pkg/run"
@@ -25,11 +26,12 @@ import (
 
 // getCmd represents the get command
 var getCmd = &cobra.Command{
-	Use:   "get",
-	Short: "Get a named phrase",
-	Long:  `Retrieve a named phrase value`,
-	RunE:  run.Get,
-	Args:  cobra.ExactArgs(1),
+	Use:               "get [NAME]",
+	Short:             "Get a named phrase",
+	Long:              `Retrieve a named phrase value`,
+	RunE:              run.Get,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSecretName,
 }
 
 func init() {
@@ -39,5 +41,10 @@ func init() {
 
 	f.String(b(flags.Version), "latest", "Get specific version")
 	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
 	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+	f.String(b(flags.Derive), "", "Derive seed or xprv from a mnemonic secret")
+	f.String(b(flags.Bip39Passphrase), "", "BIP39 passphrase for deriving from a mnemonic secret")
+	f.Bool(b(flags.Clip), false, "Copy value to terminal clipboard via OSC 52 instead of printing it")
+	f.Duration(b(flags.ClipTimeout), 45*time.Second, "Overwrite clipboard after timeout, 0 to keep value")
 }
@@ -0,0 +1,52 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var importDotenvCmdLong = `Import a dotenv file storing one secret per key.
+Keys are normalized to secret names in DNS1123 label format, for instance
+DB_PASSWORD is stored as db-password, and a mapping report is printed.
+Each value is added as a new version if the secret already exists`
+
+// importDotenvCmd represents the import dotenv command
+var importDotenvCmd = &cobra.Command{
+	Use:     "dotenv FILE",
+	Short:   "Import a dotenv file",
+	Long:    importDotenvCmdLong,
+	RunE:    run.ImportDotenv,
+	Args:    cobra.ExactArgs(1),
+	Example: fmt.Sprintf("%s import dotenv .env --encrypt", app.Name),
+}
+
+func init() {
+	importCmd.AddCommand(importDotenvCmd)
+	f := importDotenvCmd.Flags()
+	b := filepath.Base
+
+	f.Bool(b(flags.Encrypt), false, "Turn on encryption (true when passphrase is provided)")
+	f.String(flags.Passphrase, "", "Encryption passphrase")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/k8s"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var k8sManifestCmdLong = `Generate a Kubernetes v1.Secret manifest populated with
+decrypted secret values. Each --key flag maps a key of the Kubernetes
+secret to a secret name and an optional version in the form
+key=name[@version].
+
+Secret types dockerconfigjson and tls require keys .dockerconfigjson
+and tls.crt, tls.key respectively. Output is yaml unless json
+output format is requested`
+
+// k8sManifestCmd represents the k8s-manifest command
+var k8sManifestCmd = &cobra.Command{
+	Use:   "k8s-manifest",
+	Short: "Generate Kubernetes secret manifest",
+	Long:  k8sManifestCmdLong,
+	RunE:  run.K8sManifest,
+	Args:  cobra.ExactArgs(0),
+	Example: fmt.Sprintf(
+		"%s k8s-manifest --name app-secrets --key password=db-password --key token=api-token",
+		app.Name,
+	),
+}
+
+func init() {
+	rootCmd.AddCommand(k8sManifestCmd)
+	f := k8sManifestCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Name), "", "Name of the Kubernetes secret")
+	f.String(b(flags.Namespace), "", "Namespace of the Kubernetes secret")
+	f.StringSlice(b(flags.Key), nil, "Key mapping in the form key=name[@version]")
+	f.StringToString(b(flags.Label), nil, "Labels of the Kubernetes secret (e.g. app=web)")
+	f.String(b(flags.Type), k8s.SecretTypeOpaque, "Secret type (opaque, dockerconfigjson, tls)")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+
+	_ = k8sManifestCmd.RegisterFlagCompletionFunc(
+		flags.Type,
+		func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string,
+		) (
+			[]string,
+			cobra.ShellCompDirective,
+		) {
+			return k8s.SecretTypes(), cobra.ShellCompDirectiveDefault
+		},
+	)
+}
@@ -17,19 +17,25 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
 	"github.com/kubetrail/mksecret/pkg/run"
 	"github.com/spf13/cobra"
 )
 
 var listCmdLong = `List all named phrases managed by this app
-Internally it filters all secrets using label: labelKey=appName`
+Internally it filters all secrets using label: labelKey=appName
+
+Names can be organized in path-style namespaces such as team/app/name.
+Provide a prefix such as team/ to list secrets within a namespace
+and use --tree to show namespaces as a tree`
 
 // listCmd represents the list command
 var listCmd = &cobra.Command{
-	Use:   "list",
+	Use:   "list [PREFIX]",
 	Short: "List named phrases",
 	Long: strings.ReplaceAll(
 		strings.ReplaceAll(
@@ -40,11 +46,16 @@ var listCmd = &cobra.Command{
 		"appName",
 		app.Name,
 	),
-	RunE:    run.List,
-	Args:    cobra.ExactArgs(0),
-	Example: fmt.Sprintf("%s list", app.Name),
+	RunE:              run.List,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s list --tree team/", app.Name),
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	f := listCmd.Flags()
+	b := filepath.Base
+
+	f.Bool(b(flags.Tree), false, "Show namespaces as a tree")
 }
@@ -0,0 +1,55 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+// completeSecretName completes first arg with names of managed secrets
+func completeSecretName(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) (
+	[]string,
+	cobra.ShellCompDirective,
+) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completeSecretNameFlag(cmd, args, toComplete)
+}
+
+// completeSecretNameFlag completes flag value with names of managed secrets
+func completeSecretNameFlag(
+	cmd *cobra.Command,
+	args []string,
+	toComplete string,
+) (
+	[]string,
+	cobra.ShellCompDirective,
+) {
+	names, err := run.SecretNames(cmd, toComplete)
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
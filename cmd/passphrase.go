@@ -0,0 +1,30 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/spf13/pflag"
+)
+
+// addPassphraseSourceFlags adds flags for reading encryption passphrase
+// from non-interactive sources
+func addPassphraseSourceFlags(f *pflag.FlagSet) {
+	f.String(flags.PassphraseFile, "", "Read encryption passphrase from file")
+	f.String(flags.PassphraseEnv, "", "Read encryption passphrase from env. var")
+	f.Int(flags.PassphraseFd, -1, "Read encryption passphrase from file descriptor")
+	f.String(flags.PassphraseCommand, "", "Read encryption passphrase from command output")
+}
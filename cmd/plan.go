@@ -0,0 +1,71 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var planCmdLong = `Compare secrets declared in a manifest file with secrets
+managed by this app and list changes required to reconcile them.
+Only secret metadata is compared, payloads are never accessed.
+
+Manifest declares secret names, labels, replication, ttl or expireTime,
+rotation, topics, whether encryption is required and an optional value
+source:
+
+secrets:
+  - name: db-password
+    labels:
+      env: prod
+    replication:
+      locations: [us-east1, us-west1]
+    ttl: 720h
+    rotation:
+      period: 720h
+      nextRotationTime: "2026-11-01T00:00:00Z"
+    topics:
+      - projects/my-project/topics/rotation
+    encrypted: true
+    value:
+      env: DB_PASSWORD
+
+Secrets not declared in the manifest are deleted only with --prune`
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:     "plan",
+	Short:   "Show changes required to match a secrets manifest",
+	Long:    planCmdLong,
+	RunE:    run.Plan,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s plan -f secrets.yaml", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	f := planCmd.Flags()
+	b := filepath.Base
+
+	f.StringP(b(flags.File), "f", "", "Secrets manifest file")
+	f.Bool(b(flags.Prune), false, "Delete secrets not declared in the manifest")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/k8s"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var providerManifestCmdLong = `Generate External Secrets Operator ExternalSecret or
+Secrets Store CSI driver SecretProviderClass manifests referencing
+secrets managed by this app by name, version and project.
+
+Secrets are selected using --key flags in the form key=name[@version]
+and/or a --selector matching secret labels, in which case the secret
+name is used as the key. Only secret metadata is read, payloads are
+never accessed`
+
+// providerManifestCmd represents the provider-manifest command
+var providerManifestCmd = &cobra.Command{
+	Use:   "provider-manifest",
+	Short: "Generate ExternalSecret or SecretProviderClass manifest",
+	Long:  providerManifestCmdLong,
+	RunE:  run.ProviderManifest,
+	Args:  cobra.ExactArgs(0),
+	Example: fmt.Sprintf(
+		"%s provider-manifest --kind external-secret --name app-secrets --secret-store gcp-store --selector app=web",
+		app.Name,
+	),
+}
+
+func init() {
+	rootCmd.AddCommand(providerManifestCmd)
+	f := providerManifestCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Kind), k8s.KindExternalSecret, "Manifest kind (external-secret, secret-provider-class)")
+	f.String(b(flags.Name), "", "Name of the manifest and target Kubernetes secret")
+	f.String(b(flags.Namespace), "", "Namespace of the manifest")
+	f.StringSlice(b(flags.Key), nil, "Key mapping in the form key=name[@version]")
+	f.StringP(b(flags.Selector), "l", "", "Label selector to filter secrets (e.g. key1=value1,key2!=value2)")
+	f.StringToString(b(flags.Label), nil, "Labels of the manifest (e.g. app=web)")
+	f.String(b(flags.SecretStore), "", "Name of the External Secrets Operator secret store")
+	f.String(b(flags.SecretStoreKind), k8s.SecretStoreKindNamespaced, "Secret store kind (SecretStore, ClusterSecretStore)")
+	f.String(b(flags.RefreshInterval), "1h", "ExternalSecret refresh interval")
+
+	_ = providerManifestCmd.RegisterFlagCompletionFunc(
+		flags.Kind,
+		func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string,
+		) (
+			[]string,
+			cobra.ShellCompDirective,
+		) {
+			return []string{
+					k8s.KindExternalSecret,
+					k8s.KindSecretProviderClass,
+				},
+				cobra.ShellCompDirectiveDefault
+		},
+	)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+)
+
+func TestProviderManifestKeyCollidesWithSelectedSecret(t *testing.T) {
+	labels := map[string]string{app.KeyManagedBy: app.Name, "app": "web"}
+	fake.addSecret("projects/test-project/secrets/web-password", labels, []byte("password"))
+	fake.addSecret("projects/test-project/secrets/web-token", labels, []byte("token"))
+
+	// key web-token refers to web-password, so selected secret
+	// web-token cannot use its name as key
+	_, _, err := execute(
+		[]string{
+			"provider-manifest", "--name=web", "--secret-store=gcp",
+			"--key=web-token=web-password", "--selector=app=web",
+			"--google-project-id=test-project",
+		},
+	)
+	if err == nil || !strings.Contains(err.Error(), "collides with key web-token") {
+		t.Fatalf("expected key collision error, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var renameCmdLong = `Rename a secret by copying its metadata and all versions
+in order to a new secret, preserving disabled state of versions.
+Copies are verified by reading them back and the old secret is deleted
+only after confirmation. Destroyed versions are not copied`
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:               "rename OLD NEW",
+	Short:             "Rename a secret",
+	Long:              renameCmdLong,
+	RunE:              run.Rename,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s rename db-pass db-password", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+	f := renameCmd.Flags()
+	b := filepath.Base
+
+	f.Bool(b(flags.Force), false, "Force delete of old secret without asking confirmation")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var renderCmdLong = `Render a Go text/template file using secret values.
+Following template functions are available:
+  {{ secret "name" }}                 latest version of a secret
+  {{ secretVersion "name" "3" }}      specific version of a secret
+  {{ field "name" "key" }}            field of a secret stored as json object
+
+Output is written to STDOUT or to a file with 0600 permissions`
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:     "render",
+	Short:   "Render a template using secrets",
+	Long:    renderCmdLong,
+	RunE:    run.Render,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s render -f config.tmpl --out config.yaml", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+	f := renderCmd.Flags()
+	b := filepath.Base
+
+	f.StringP(b(flags.File), "f", "", "Template file")
+	f.String(b(flags.Out), "", "Output file (default is STDOUT)")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
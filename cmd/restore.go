@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmdLong = `Recreate secrets from an encrypted bundle in the project.
+Enabled versions are added in their original order, however, version
+numbers restart from 1 in new secrets.
+
+Conflict policy decides what happens when a secret already exists:
+  skip:        leave existing secret unchanged
+  overwrite:   replace metadata and versions of existing secret with the
+               bundle, destroying existing versions only after bundle
+               versions have been added
+  new-version: add bundle versions as new versions of existing secret
+
+Expiration in the past is dropped and rotation in the past is moved
+forward by whole rotation periods. Pub/Sub topics and hence rotation
+are only restored into the project that was backed up, unless
+--copy-topics is set`
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:     "restore FILE",
+	Short:   "Restore secrets from an encrypted bundle",
+	Long:    restoreCmdLong,
+	RunE:    run.Restore,
+	Args:    cobra.ExactArgs(1),
+	Example: fmt.Sprintf("%s restore bundle.msb --google-project-id=other-project --conflict=skip", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	f := restoreCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Conflict), run.ConflictSkip, "Conflict policy (skip, overwrite, new-version)")
+	f.String(b(flags.Identity), "", "Identity file for bundles encrypted for recipients")
+	f.Bool(b(flags.CopyTopics), false, "Restore Pub/Sub topics into a project other than the one backed up")
+	f.String(flags.Passphrase, "", "Bundle encryption passphrase")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+
+	_ = restoreCmd.RegisterFlagCompletionFunc(
+		flags.Conflict,
+		func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string,
+		) (
+			[]string,
+			cobra.ShellCompDirective,
+		) {
+			return []string{
+					run.ConflictSkip,
+					run.ConflictOverwrite,
+					run.ConflictNewVersion,
+				},
+				cobra.ShellCompDirectiveDefault
+		},
+	)
+}
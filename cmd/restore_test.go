@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/bundle"
+	"github.com/kubetrail/mksecret/pkg/run"
+)
+
+func TestRestoreDropsTopicsOfOtherProject(t *testing.T) {
+	const passphrase = "vX9#qL2@mN7$wR4!"
+
+	next := time.Now().Add(time.Hour)
+	b := &bundle.Bundle{
+		Project:    "deleted-project",
+		CreateTime: time.Now(),
+		Secrets: []bundle.Secret{
+			{
+				Name:             "rotated",
+				Labels:           map[string]string{app.KeyManagedBy: app.Name},
+				Topics:           []string{"projects/deleted-project/topics/rotation"},
+				RotationPeriod:   24 * time.Hour,
+				NextRotationTime: &next,
+				Versions: []bundle.Version{
+					{Version: "1", Payload: []byte("value"), Crc32c: int64(run.Crc32Sum([]byte("value")))},
+				},
+			},
+		},
+	}
+
+	data, err := bundle.Seal(b, passphrase, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "bundle.msb")
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := execute(
+		[]string{
+			"restore", filename, "--passphrase=" + passphrase,
+			"--google-project-id=test-project", "--output-format=json",
+		},
+	); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	secret := fake.secret("projects/test-project/secrets/rotated")
+	if secret == nil {
+		t.Fatal("secret was not restored")
+	}
+
+	if len(secret.GetTopics()) > 0 || secret.GetRotation() != nil {
+		t.Errorf("topics of other project were restored: %v, %v", secret.GetTopics(), secret.GetRotation())
+	}
+
+	if payloads := fake.payloads(secret.GetName()); len(payloads) != 1 || string(payloads[0]) != "value" {
+		t.Errorf("unexpected restored payloads %q", payloads)
+	}
+}
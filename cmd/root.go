@@ -16,10 +16,13 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/kubetrail/mksecret/pkg/config"
 	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -40,11 +43,20 @@ securely on Google secrets manager`,
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	err := rootCmd.Execute()
+
+	// exit code of a child process is propagated as is
+	var exitErr *run.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+
+	cobra.CheckErr(err)
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentPreRunE = applyContext
 	f := rootCmd.PersistentFlags()
 
 	// Here you will define your flags and configuration settings.
@@ -60,6 +72,21 @@ func init() {
 	f.String(flags.GoogleProjectID, "", "Google project ID (Env: GOOGLE_PROJECT_ID)")
 	f.String(flags.GoogleApplicationCredentials, "", "Google app credentials (Env: GOOGLE_APPLICATION_CREDENTIALS)")
 	f.String(flags.OutputFormat, flags.OutputFormatNative, "Output format (native, json, yaml, table)")
+	f.String(flags.Context, "", "Context from config file to use instead of current context")
+
+	_ = rootCmd.RegisterFlagCompletionFunc(
+		flags.Context,
+		func(
+			cmd *cobra.Command,
+			args []string,
+			toComplete string,
+		) (
+			[]string,
+			cobra.ShellCompDirective,
+		) {
+			return contextNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+	)
 
 	_ = rootCmd.RegisterFlagCompletionFunc(
 		flags.OutputFormat,
@@ -105,3 +132,54 @@ func initConfig() {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
+
+// applyContext applies settings of context in use before running a
+// command. An invalid context is only warned about for config commands
+// and completions so that it can be fixed using config commands.
+func applyContext(cmd *cobra.Command, args []string) error {
+	err := useContext()
+	if err == nil {
+		return nil
+	}
+
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == configCmd || c.Name() == cobra.ShellCompRequestCmd || c.Name() == cobra.ShellCompNoDescRequestCmd {
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Warning:", err)
+			return nil
+		}
+	}
+
+	// error is in config file, not in usage of command
+	cmd.SilenceUsage = true
+	return err
+}
+
+// useContext merges settings of context from flag, else of current
+// context, over top level settings of config file. Flags and env.
+// vars still take precedence.
+func useContext() error {
+	name, _ := rootCmd.PersistentFlags().GetString(flags.Context)
+	if len(name) == 0 {
+		name = viper.GetString(flags.CurrentContext)
+	}
+
+	if len(name) == 0 {
+		return nil
+	}
+
+	contexts := make(map[string]config.Context)
+	if err := viper.UnmarshalKey(flags.Contexts, &contexts); err != nil {
+		return fmt.Errorf("failed to parse contexts in config file: %w", err)
+	}
+
+	c, ok := contexts[name]
+	if !ok {
+		return fmt.Errorf("context %s not found in config file", name)
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid context %s: %w", name, err)
+	}
+
+	return viper.MergeConfigMap(c.Settings())
+}
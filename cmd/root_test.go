@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/agent"
+	"github.com/kubetrail/mksecret/pkg/prompter"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"google.golang.org/api/option"
+)
+
+var (
+	// fake is the secret manager commands under test talk to
+	fake *fakeSecretManager
+
+	// script answers prompts of the command being executed
+	script = &scriptPrompter{}
+
+	// testCtx is shared by all executions, since cobra retains
+	// context of a subcommand once it was executed
+	testCtx context.Context
+)
+
+// scriptPrompter delegates to the script of the current execution
+type scriptPrompter struct {
+	*prompter.Script
+}
+
+func TestMain(m *testing.M) {
+	var opts []option.ClientOption
+	var stop func()
+	fake, opts, stop = newFakeSecretManager()
+
+	testCtx = prompter.WithContext(run.WithClientOptions(context.Background(), opts...), script)
+
+	home, err := os.MkdirTemp("", "mksecret-test-")
+	if err != nil {
+		panic(err)
+	}
+
+	_ = os.Setenv("HOME", home)
+	_ = os.Unsetenv(agent.EnvAuthSock)
+
+	code := m.Run()
+
+	stop()
+	_ = os.RemoveAll(home)
+	os.Exit(code)
+}
+
+// execute runs root command with args answering prompts with inputs
+// and returns command output along with script of prompts
+func execute(args []string, inputs ...string) (string, *prompter.Script, error) {
+	script.Script = prompter.NewScript(inputs...)
+
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetIn(&bytes.Buffer{})
+	rootCmd.SetArgs(args)
+
+	err := rootCmd.ExecuteContext(testCtx)
+
+	return stdout.String(), script.Script, err
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/option"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeSecretManager is an in-memory secret manager implementing
+// the calls commands under test make
+type fakeSecretManager struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+
+	mu       sync.Mutex
+	secrets  map[string]*secretmanagerpb.Secret
+	versions map[string][]*fakeVersion
+}
+
+type fakeVersion struct {
+	version *secretmanagerpb.SecretVersion
+	payload []byte
+}
+
+// newFakeSecretManager starts a fake secret manager and returns client
+// options pointing to it along with a func stopping it
+func newFakeSecretManager() (*fakeSecretManager, []option.ClientOption, func()) {
+	fake := &fakeSecretManager{
+		secrets:  make(map[string]*secretmanagerpb.Secret),
+		versions: make(map[string][]*fakeVersion),
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	secretmanagerpb.RegisterSecretManagerServiceServer(server, fake)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	opts := []option.ClientOption{
+		option.WithEndpoint("bufnet"),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithGRPCDialOption(
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			}),
+		),
+	}
+
+	return fake, opts, server.Stop
+}
+
+// payloads returns stored payloads of a secret
+func (f *fakeSecretManager) payloads(secretName string) [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var payloads [][]byte
+	for _, v := range f.versions[secretName] {
+		payloads = append(payloads, v.payload)
+	}
+
+	return payloads
+}
+
+// addSecret stores a secret with labels and payloads as its versions
+func (f *fakeSecretManager) addSecret(secretName string, labels map[string]string, payloads ...[]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.secrets[secretName] = &secretmanagerpb.Secret{
+		Name:       secretName,
+		Labels:     labels,
+		CreateTime: timestamppb.Now(),
+	}
+
+	for i, payload := range payloads {
+		f.versions[secretName] = append(
+			f.versions[secretName],
+			&fakeVersion{
+				version: &secretmanagerpb.SecretVersion{
+					Name:       fmt.Sprintf("%s/versions/%d", secretName, i+1),
+					CreateTime: timestamppb.Now(),
+					State:      secretmanagerpb.SecretVersion_ENABLED,
+				},
+				payload: payload,
+			},
+		)
+	}
+}
+
+// secret returns a secret or nil if it does not exist
+func (f *fakeSecretManager) secret(secretName string) *secretmanagerpb.Secret {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.secrets[secretName]
+}
+
+// labels returns labels of a secret
+func (f *fakeSecretManager) labels(secretName string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.secrets[secretName].GetLabels()
+}
+
+func (f *fakeSecretManager) CreateSecret(
+	_ context.Context,
+	req *secretmanagerpb.CreateSecretRequest,
+) (*secretmanagerpb.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := fmt.Sprintf("%s/secrets/%s", req.GetParent(), req.GetSecretId())
+	if _, ok := f.secrets[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "secret %s already exists", name)
+	}
+
+	secret := proto.Clone(req.GetSecret()).(*secretmanagerpb.Secret)
+	secret.Name = name
+	secret.CreateTime = timestamppb.Now()
+	f.secrets[name] = secret
+
+	return secret, nil
+}
+
+func (f *fakeSecretManager) ListSecrets(
+	_ context.Context,
+	req *secretmanagerpb.ListSecretsRequest,
+) (*secretmanagerpb.ListSecretsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resp := &secretmanagerpb.ListSecretsResponse{}
+	for name, secret := range f.secrets {
+		if strings.HasPrefix(name, req.GetParent()+"/secrets/") {
+			resp.Secrets = append(resp.Secrets, secret)
+		}
+	}
+	resp.TotalSize = int32(len(resp.Secrets))
+
+	return resp, nil
+}
+
+func (f *fakeSecretManager) GetSecret(
+	_ context.Context,
+	req *secretmanagerpb.GetSecretRequest,
+) (*secretmanagerpb.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, ok := f.secrets[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.GetName())
+	}
+
+	return secret, nil
+}
+
+func (f *fakeSecretManager) UpdateSecret(
+	_ context.Context,
+	req *secretmanagerpb.UpdateSecretRequest,
+) (*secretmanagerpb.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secret, ok := f.secrets[req.GetSecret().GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.GetSecret().GetName())
+	}
+
+	for _, p := range req.GetUpdateMask().GetPaths() {
+		if p == "labels" {
+			secret.Labels = req.GetSecret().GetLabels()
+		}
+	}
+
+	return secret, nil
+}
+
+func (f *fakeSecretManager) DeleteSecret(
+	_ context.Context,
+	req *secretmanagerpb.DeleteSecretRequest,
+) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.secrets[req.GetName()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.GetName())
+	}
+
+	delete(f.secrets, req.GetName())
+	delete(f.versions, req.GetName())
+
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeSecretManager) AddSecretVersion(
+	_ context.Context,
+	req *secretmanagerpb.AddSecretVersionRequest,
+) (*secretmanagerpb.SecretVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.secrets[req.GetParent()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "secret %s not found", req.GetParent())
+	}
+
+	version := &secretmanagerpb.SecretVersion{
+		Name:       fmt.Sprintf("%s/versions/%d", req.GetParent(), len(f.versions[req.GetParent()])+1),
+		CreateTime: timestamppb.Now(),
+		State:      secretmanagerpb.SecretVersion_ENABLED,
+	}
+
+	f.versions[req.GetParent()] = append(
+		f.versions[req.GetParent()],
+		&fakeVersion{
+			version: version,
+			payload: req.GetPayload().GetData(),
+		},
+	)
+
+	return version, nil
+}
+
+func (f *fakeSecretManager) AccessSecretVersion(
+	_ context.Context,
+	req *secretmanagerpb.AccessSecretVersionRequest,
+) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	secretName, id, _ := strings.Cut(req.GetName(), "/versions/")
+	versions := f.versions[secretName]
+
+	var found *fakeVersion
+	for _, v := range versions {
+		if v.version.GetState() != secretmanagerpb.SecretVersion_ENABLED {
+			continue
+		}
+
+		if id == "latest" || v.version.GetName() == req.GetName() {
+			found = v
+		}
+	}
+
+	if found == nil {
+		return nil, status.Errorf(codes.NotFound, "version %s not found", req.GetName())
+	}
+
+	crc := int64(crc32.Checksum(found.payload, crc32.MakeTable(crc32.Castagnoli)))
+
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name: found.version.GetName(),
+		Payload: &secretmanagerpb.SecretPayload{
+			Data:       found.payload,
+			DataCrc32C: &crc,
+		},
+	}, nil
+}
+
+func (f *fakeSecretManager) ListSecretVersions(
+	_ context.Context,
+	req *secretmanagerpb.ListSecretVersionsRequest,
+) (*secretmanagerpb.ListSecretVersionsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	resp := &secretmanagerpb.ListSecretVersionsResponse{}
+	for _, v := range f.versions[req.GetParent()] {
+		if req.GetFilter() == "state:ENABLED" && v.version.GetState() != secretmanagerpb.SecretVersion_ENABLED {
+			continue
+		}
+
+		resp.Versions = append(resp.Versions, v.version)
+	}
+	resp.TotalSize = int32(len(resp.Versions))
+
+	return resp, nil
+}
@@ -16,9 +16,12 @@ limitations under the License.
 package cmd
 
 import (
+	"fmt"
 	"path/filepath"
 
+	"github.com/kubetrail/bip39/pkg/mnemonics"
 	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/generate"
 	"github.com/kubetrail/mksecret/pkg/run"
 	"github.com/spf13/cobra"
 )
@@ -36,28 +39,26 @@ func init() {
 	f := setCmd.Flags()
 	b := filepath.Base
 
-	f.String(b(flags.Name), "", "Name tag for the secret (DNS1123 label format)")
+	f.String(b(flags.Name), "", "Name tag for the secret, DNS1123 labels optionally in namespaces such as team/app/name")
 	f.Bool(b(flags.Encrypt), false, "Turn on encryption (true when passphrase is provided)")
 	f.String(flags.Passphrase, "", "Encryption passphrase")
+	addPassphraseSourceFlags(f)
 	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+	f.Bool(b(flags.Generate), false, "Generate a random secret value")
+	f.String(b(flags.Type), "", "Secret type (mnemonic or tls), values are validated or generated as per type")
+	f.String(b(flags.Language), mnemonics.LanguageEnglish, "Mnemonic language")
+	f.StringP(b(flags.File), "f", "", "Read secret value from file")
+	f.String(b(flags.CommonName), "", "Common name of generated tls certificate")
+	f.StringSlice(b(flags.Hosts), nil, "DNS names and IP addresses of generated tls certificate")
+	f.String(b(flags.ValidFor), "365d", "Validity of generated tls certificate (e.g. 90d or 2160h)")
+	f.String(b(flags.Ca), "", "Name of tls secret holding ca to sign generated certificate (default self-signed)")
+	f.Bool(b(flags.IsCa), false, "Generate tls certificate as a ca")
+	f.String(b(flags.Kind), generate.KindPassword, fmt.Sprintf("Kind of generated value %v", generate.Kinds()))
+	f.Int(b(flags.Length), 0, "Length of generated password or mnemonic, or number of random bytes of generated token")
+	f.StringSlice(b(flags.Charset), generate.Classes(), "Character classes of generated password")
+	f.Bool(b(flags.ExcludeAmbiguous), false, "Exclude ambiguous characters from generated password")
+	f.Int(b(flags.Words), generate.DefaultWords, "Number of words of generated diceware passphrase")
+	f.String(b(flags.Separator), generate.DefaultSeparator, "Separator of generated diceware passphrase words")
 
-	_ = setCmd.RegisterFlagCompletionFunc(
-		flags.Name,
-		func(
-			cmd *cobra.Command,
-			args []string,
-			toComplete string,
-		) (
-			[]string,
-			cobra.ShellCompDirective,
-		) {
-			return []string{
-					"example-names-in-dns1123-format",
-					"my-mnemonic-1",
-					"eth-hex-seed-key",
-					"my-super-secret",
-				},
-				cobra.ShellCompDirectiveDefault
-		},
-	)
+	_ = setCmd.RegisterFlagCompletionFunc(flags.Name, completeSecretNameFlag)
 }
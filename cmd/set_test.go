@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSetGetJsonOutput(t *testing.T) {
+	const (
+		value      = "s3cr3t-v4lue"
+		passphrase = "vX9#qL2@mN7$wR4!"
+		secretName = "projects/test-project/secrets/db-password"
+	)
+
+	stdout, _, err := execute(
+		[]string{
+			"set", "--name=db-password", "--encrypt",
+			"--google-project-id=test-project", "--output-format=json",
+		},
+		value, passphrase, passphrase,
+	)
+	if err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+
+	assertSingleJson(t, stdout)
+
+	payloads := fake.payloads(secretName)
+	if len(payloads) != 1 {
+		t.Fatalf("expected one stored version, got %d", len(payloads))
+	}
+
+	if bytes.Contains(payloads[0], []byte(value)) {
+		t.Fatalf("stored payload contains plaintext value")
+	}
+
+	stdout, prompts, err := execute(
+		[]string{
+			"get", "db-password",
+			"--google-project-id=test-project", "--output-format=json",
+		},
+		passphrase,
+	)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+
+	assertSingleJson(t, stdout)
+
+	if !bytes.Contains([]byte(stdout), []byte(value)) {
+		t.Fatalf("get output does not contain secret value: %s", stdout)
+	}
+
+	if want := []string{"Enter secret passphrase"}; !reflect.DeepEqual(prompts.Prompts, want) {
+		t.Fatalf("expected prompts %q, got %q", want, prompts.Prompts)
+	}
+}
+
+// assertSingleJson fails unless output is exactly one JSON value
+func assertSingleJson(t *testing.T, output string) {
+	t.Helper()
+
+	decoder := json.NewDecoder(bytes.NewBufferString(output))
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		t.Fatalf("output is not JSON: %v: %q", err, output)
+	}
+
+	if decoder.More() {
+		t.Fatalf("output has content after JSON value: %q", output)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var shellCmdLong = `Open an interactive shell sharing one Secret Manager client.
+Commands ls, get, set, versions, describe and rm work on secrets
+and tab completes command and secret names.
+
+Passphrases that decrypt a secret are kept for the session and tried
+before prompting again. Secret values and passphrases are read without
+echo and are never recorded in shell history`
+
+// shellCmd represents the shell command
+var shellCmd = &cobra.Command{
+	Use:     "shell",
+	Short:   "Open an interactive shell",
+	Long:    shellCmdLong,
+	RunE:    run.Shell,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s shell", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+	f := shellCmd.Flags()
+
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var sshAddCmdLong = `Write private key of an SSH key secret in OpenSSH format
+so that it can be piped to ssh-add. Key is decrypted if required`
+
+// sshAddCmd represents the ssh-add command
+var sshAddCmd = &cobra.Command{
+	Use:               "ssh-add NAME",
+	Short:             "Output SSH private key for ssh-add",
+	Long:              sshAddCmdLong,
+	RunE:              run.SshAdd,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSecretName,
+	Example:           fmt.Sprintf("%s ssh-add deploy-key | ssh-add -", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(sshAddCmd)
+	f := sshAddCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Version), "latest", "Secret version")
+	f.String(flags.Passphrase, "", "Encryption passphrase if required")
+	addPassphraseSourceFlags(f)
+	f.Bool(flags.NoPrompt, false, "Hide all prompts")
+}
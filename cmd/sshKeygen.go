@@ -0,0 +1,56 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/kubetrail/mksecret/pkg/sshkeys"
+	"github.com/spf13/cobra"
+)
+
+var sshKeygenCmdLong = `Generate an SSH key pair and store the private key in OpenSSH
+format as a new version of the named secret, encrypting it if requested.
+Public key is stored in authorized keys format as a new version of a
+sibling secret named with -pub suffix and is never encrypted`
+
+// sshKeygenCmd represents the ssh-keygen command
+var sshKeygenCmd = &cobra.Command{
+	Use:     "ssh-keygen",
+	Short:   "Generate and store an SSH key pair",
+	Long:    sshKeygenCmdLong,
+	RunE:    run.SshKeygen,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s ssh-keygen --name=deploy-key --type=ed25519 --encrypt", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(sshKeygenCmd)
+	f := sshKeygenCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.Name), "", "Name tag for the secret, DNS1123 labels optionally in namespaces such as team/app/name")
+	f.String(b(flags.Type), sshkeys.TypeEd25519, fmt.Sprintf("Key type %v", sshkeys.Types()))
+	f.Int(b(flags.Bits), sshkeys.DefaultRsaBits, "Number of bits for rsa keys")
+	f.String(b(flags.Comment), "", "Comment for the public key")
+	f.Bool(b(flags.Encrypt), false, "Turn on encryption (true when passphrase is provided)")
+	f.String(flags.Passphrase, "", "Encryption passphrase")
+	addPassphraseSourceFlags(f)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/spf13/viper"
+)
+
+func TestSshKeygenEncryptedNamespace(t *testing.T) {
+	const passphrase = "vX9#qL2@mN7$wR4!"
+
+	viper.Set(flags.Namespaces, map[string]interface{}{
+		"team": map[string]interface{}{"encrypt": true},
+	})
+	t.Cleanup(func() {
+		viper.Set(flags.Namespaces, map[string]interface{}{})
+	})
+
+	stdout, _, err := execute(
+		[]string{
+			"ssh-keygen", "--name=team/host",
+			"--google-project-id=test-project", "--output-format=json",
+		},
+		passphrase, passphrase,
+	)
+	if err != nil {
+		t.Fatalf("ssh-keygen failed: %v", err)
+	}
+
+	assertSingleJson(t, stdout)
+
+	const (
+		keyName = "projects/test-project/secrets/team_host"
+		pubName = "projects/test-project/secrets/team_host-pub"
+	)
+
+	if fake.labels(keyName)[app.KeyEncrypted] != app.ValueTrue {
+		t.Errorf("private key secret is not labeled as encrypted: %v", fake.labels(keyName))
+	}
+
+	if _, ok := fake.labels(pubName)[app.KeyEncrypted]; ok {
+		t.Errorf("public key secret is labeled as encrypted: %v", fake.labels(pubName))
+	}
+
+	payloads := fake.payloads(pubName)
+	if len(payloads) != 1 || !bytes.HasPrefix(payloads[0], []byte("ssh-ed25519 ")) {
+		t.Fatalf("public key is not stored as plaintext: %q", payloads)
+	}
+
+	stdout, prompts, err := execute(
+		[]string{
+			"get", "team/host-pub",
+			"--google-project-id=test-project", "--output-format=json",
+		},
+	)
+	if err != nil {
+		t.Fatalf("get of public key failed: %v", err)
+	}
+
+	assertSingleJson(t, stdout)
+
+	if len(prompts.Prompts) > 0 {
+		t.Errorf("get of public key prompted %q", prompts.Prompts)
+	}
+}
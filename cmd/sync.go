@@ -0,0 +1,57 @@
+/*
+Copyright © 2022 kubetrail.io authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/run"
+	"github.com/spf13/cobra"
+)
+
+var syncCmdLong = `Replicate secrets managed by this app from one project to
+another. Missing secrets are created, labels are updated and the latest
+enabled version is added to destination secrets whose latest version
+differs. Payloads are copied byte-for-byte, so secrets encrypted by
+this app remain encrypted with the same passphrase.
+
+Topics are copied only when --copy-topics is set`
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:     "sync",
+	Short:   "Sync secrets between projects",
+	Long:    syncCmdLong,
+	RunE:    run.Sync,
+	Args:    cobra.ExactArgs(0),
+	Example: fmt.Sprintf("%s sync --from-project staging --to-project prod --selector app=web --dry-run", app.Name),
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	f := syncCmd.Flags()
+	b := filepath.Base
+
+	f.String(b(flags.FromProject), "", "Source project (default is Google project ID)")
+	f.String(b(flags.ToProject), "", "Destination project")
+	f.StringP(b(flags.Selector), "l", "", "Label selector to filter secrets (e.g. key1=value1,key2!=value2)")
+	f.Bool(b(flags.AllVersions), false, "Copy all enabled versions missing in destination secrets")
+	f.Bool(b(flags.CopyTopics), false, "Copy Pub/Sub topics to destination project")
+	f.Bool(b(flags.DryRun), false, "Show changes without making them")
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Client talks to an agent over its unix socket
+type Client struct {
+	socket string
+}
+
+// NewClient creates a client for agent listening on socket
+func NewClient(socket string) *Client {
+	return &Client{socket: socket}
+}
+
+// NewClientFromEnv creates a client using socket path from env. var.
+// It returns false when env. var is not set.
+func NewClientFromEnv() (*Client, bool) {
+	socket, ok := os.LookupEnv(EnvAuthSock)
+	if !ok || len(socket) == 0 {
+		return nil, false
+	}
+
+	return NewClient(socket), true
+}
+
+// Add adds a derived key to the agent
+func (c *Client) Add(key []byte) error {
+	_, err := c.call(&request{Op: opAdd, Data: key})
+	return err
+}
+
+// Encrypt encrypts plaintext using the key that decrypts ciphertext,
+// such as that of an existing version, so that plaintext is protected
+// by the same key
+func (c *Client) Encrypt(plaintext, ciphertext []byte) ([]byte, error) {
+	resp, err := c.call(&request{Op: opEncrypt, Data: plaintext, Ciphertext: ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// Decrypt decrypts ciphertext using any key that can decrypt it
+func (c *Client) Decrypt(ciphertext []byte) ([]byte, error) {
+	resp, err := c.call(&request{Op: opDecrypt, Data: ciphertext})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// Lock locks the agent using a password
+func (c *Client) Lock(password []byte) error {
+	_, err := c.call(&request{Op: opLock, Password: password})
+	return err
+}
+
+// Unlock unlocks the agent using the password it was locked with
+func (c *Client) Unlock(password []byte) error {
+	_, err := c.call(&request{Op: opUnlock, Password: password})
+	return err
+}
+
+// Forget removes all keys from the agent
+func (c *Client) Forget() error {
+	_, err := c.call(&request{Op: opForget})
+	return err
+}
+
+func (c *Client) call(req *request) (*response, error) {
+	conn, err := net.DialTimeout("unix", c.socket, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+
+	resp := &response{}
+	if err := json.NewDecoder(conn).Decode(resp); err != nil {
+		return nil, fmt.Errorf("failed to read response from agent: %w", err)
+	}
+
+	if len(resp.Error) > 0 {
+		return nil, errors.New(resp.Error)
+	}
+
+	return resp, nil
+}
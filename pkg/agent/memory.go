@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockedKey is a key held in memory that is locked against swapping
+// and is not managed by garbage collector, so it can be reliably wiped
+type lockedKey struct {
+	buf []byte
+}
+
+// newLockedKey copies key into locked memory
+func newLockedKey(key []byte) (*lockedKey, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+
+	buf, err := unix.Mmap(-1, 0, len(key), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate memory: %w", err)
+	}
+
+	if err := unix.Mlock(buf); err != nil {
+		_ = unix.Munmap(buf)
+		return nil, fmt.Errorf("failed to lock memory: %w", err)
+	}
+
+	copy(buf, key)
+
+	return &lockedKey{buf: buf}, nil
+}
+
+// destroy wipes and releases key memory
+func (k *lockedKey) destroy() {
+	if k.buf == nil {
+		return
+	}
+
+	for i := range k.buf {
+		k.buf[i] = 0
+	}
+
+	_ = unix.Munlock(k.buf)
+	_ = unix.Munmap(k.buf)
+	k.buf = nil
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/crypto"
+)
+
+const EnvAuthSock = "MKSECRET_AUTH_SOCK"
+
+const (
+	opAdd     = "add"
+	opEncrypt = "encrypt"
+	opDecrypt = "decrypt"
+	opLock    = "lock"
+	opUnlock  = "unlock"
+	opForget  = "forget"
+)
+
+// request is sent by client, one per connection. Ciphertext selects
+// the key used for encryption.
+type request struct {
+	Op         string `json:"op"`
+	Data       []byte `json:"data,omitempty"`
+	Ciphertext []byte `json:"ciphertext,omitempty"`
+	Password   []byte `json:"password,omitempty"`
+}
+
+// response is sent by agent
+type response struct {
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server holds derived AES keys in locked memory and encrypts or
+// decrypts payloads on request so that keys never leave the agent.
+// Keys are forgotten when no request is received within idle timeout.
+type Server struct {
+	mu       sync.Mutex
+	keys     []*lockedKey
+	lockHash []byte
+	timeout  time.Duration
+	timer    *time.Timer
+}
+
+// NewServer creates a new agent server. Zero timeout disables
+// forgetting keys on idle.
+func NewServer(timeout time.Duration) *Server {
+	return &Server{timeout: timeout}
+}
+
+// Serve accepts connections until context is done, after which
+// all keys are forgotten
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+
+	defer s.forget()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+
+	req := &request{}
+	if err := json.NewDecoder(conn).Decode(req); err != nil {
+		_ = json.NewEncoder(conn).Encode(&response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	resp := s.process(req)
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+func (s *Server) process(req *request) *response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetTimer()
+
+	locked := s.lockHash != nil
+
+	switch req.Op {
+	case opLock:
+		if locked {
+			return &response{Error: "agent is already locked"}
+		}
+		if len(req.Password) == 0 {
+			return &response{Error: "lock password cannot be empty"}
+		}
+		sum := sha256.Sum256(req.Password)
+		s.lockHash = sum[:]
+		return &response{}
+	case opUnlock:
+		if !locked {
+			return &response{Error: "agent is not locked"}
+		}
+		sum := sha256.Sum256(req.Password)
+		if subtle.ConstantTimeCompare(sum[:], s.lockHash) != 1 {
+			return &response{Error: "incorrect lock password"}
+		}
+		s.lockHash = nil
+		return &response{}
+	case opForget:
+		s.forgetLocked()
+		return &response{}
+	}
+
+	if locked {
+		return &response{Error: "agent is locked"}
+	}
+
+	switch req.Op {
+	case opAdd:
+		key, err := newLockedKey(req.Data)
+		if err != nil {
+			return &response{Error: err.Error()}
+		}
+		// most recently added key is tried first
+		s.keys = append([]*lockedKey{key}, s.keys...)
+		return &response{}
+	case opEncrypt:
+		// data is only encrypted with the key protecting an existing
+		// ciphertext, never with an arbitrary key
+		if len(req.Ciphertext) == 0 {
+			return &response{Error: "ciphertext is required to select encryption key"}
+		}
+		for _, key := range s.keys {
+			if _, err := crypto.DecryptWithAesKey(req.Ciphertext, key.buf); err == nil {
+				ciphertext, err := crypto.EncryptWithAesKey(req.Data, key.buf)
+				if err != nil {
+					return &response{Error: err.Error()}
+				}
+				return &response{Data: ciphertext}
+			}
+		}
+		return &response{Error: "no key in agent can decrypt ciphertext"}
+	case opDecrypt:
+		for _, key := range s.keys {
+			if plaintext, err := crypto.DecryptWithAesKey(req.Data, key.buf); err == nil {
+				return &response{Data: plaintext}
+			}
+		}
+		return &response{Error: "no key in agent can decrypt data"}
+	default:
+		return &response{Error: fmt.Sprintf("invalid op %s", req.Op)}
+	}
+}
+
+// resetTimer restarts idle timer, caller must hold lock
+func (s *Server) resetTimer() {
+	if s.timeout <= 0 {
+		return
+	}
+
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	s.timer = time.AfterFunc(s.timeout, s.forget)
+}
+
+func (s *Server) forget() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forgetLocked()
+}
+
+// forgetLocked destroys all keys, caller must hold lock
+func (s *Server) forgetLocked() {
+	for _, key := range s.keys {
+		key.destroy()
+	}
+	s.keys = nil
+}
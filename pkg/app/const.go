@@ -5,4 +5,25 @@ const (
 	KeyManagedBy = "managed-by"
 	KeyEncrypted = "encrypted"
 	ValueTrue    = "true"
+	KeyNamespace = "namespace"
 )
+
+const (
+	KeyType      = "type"
+	TypeMnemonic = "mnemonic"
+	TypeSshKey   = "ssh-key"
+	TypeSshPub   = "ssh-public-key"
+	TypeTls      = "tls"
+	KeyNotAfter  = "not-after"
+)
+
+// IsReservedLabel reports if a label key is owned by this app and
+// hence cannot be set by users
+func IsReservedLabel(key string) bool {
+	switch key {
+	case KeyManagedBy, KeyEncrypted, KeyType, KeyNotAfter, KeyNamespace:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,304 @@
+package bundle
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	format        = "mksecret-bundle"
+	formatVersion = 1
+	saltLen       = 16
+	dataKeyLen    = 32
+)
+
+const (
+	ModePassphrase = "passphrase"
+	ModeRecipient  = "recipient"
+)
+
+const (
+	identityPrefix  = "MKSECRET-IDENTITY-"
+	recipientPrefix = "mksecret-recipient-"
+)
+
+// Bundle holds all managed secrets of a project along with
+// their enabled versions
+type Bundle struct {
+	Project    string    `json:"project"`
+	CreateTime time.Time `json:"createTime"`
+	Secrets    []Secret  `json:"secrets"`
+}
+
+// Secret holds metadata and versions of a secret
+type Secret struct {
+	Name             string            `json:"name"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Locations        []string          `json:"locations,omitempty"`
+	ExpireTime       *time.Time        `json:"expireTime,omitempty"`
+	RotationPeriod   time.Duration     `json:"rotationPeriod,omitempty"`
+	NextRotationTime *time.Time        `json:"nextRotationTime,omitempty"`
+	Topics           []string          `json:"topics,omitempty"`
+	Versions         []Version         `json:"versions"`
+}
+
+// Version holds a payload exactly as stored, hence
+// payloads encrypted by this app remain encrypted
+type Version struct {
+	Version    string    `json:"version"`
+	CreateTime time.Time `json:"createTime"`
+	Payload    []byte    `json:"payload"`
+	Crc32c     int64     `json:"crc32c"`
+}
+
+// recipientKey is the data key sealed for a recipient
+type recipientKey struct {
+	Recipient string `json:"recipient"`
+	SealedKey []byte `json:"sealedKey"`
+}
+
+// envelope is the serialized form of an encrypted bundle
+type envelope struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	Mode       string         `json:"mode"`
+	Salt       []byte         `json:"salt,omitempty"`
+	Recipients []recipientKey `json:"recipients,omitempty"`
+	Ciphertext []byte         `json:"ciphertext"`
+}
+
+// NewIdentity generates a new identity and its recipient
+// for recipient based bundle encryption
+func NewIdentity() (identity, recipient string, err error) {
+	pub, prv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
+	}
+
+	return identityPrefix + base58.Encode(prv[:]), recipientPrefix + base58.Encode(pub[:]), nil
+}
+
+// Recipient derives recipient from identity
+func Recipient(identity string) (string, error) {
+	prv, err := decodeKey(identity, identityPrefix)
+	if err != nil {
+		return "", err
+	}
+
+	pub, err := publicKey(prv)
+	if err != nil {
+		return "", err
+	}
+
+	return recipientPrefix + base58.Encode(pub[:]), nil
+}
+
+// Seal serializes, compresses and encrypts the bundle. When recipients
+// are provided, a random data key is sealed for each recipient, else
+// the key is derived from the passphrase.
+func Seal(b *Bundle, passphrase string, recipients []string) ([]byte, error) {
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize bundle: %w", err)
+	}
+
+	bb := new(bytes.Buffer)
+	zw := gzip.NewWriter(bb)
+	if _, err := zw.Write(jb); err != nil {
+		return nil, fmt.Errorf("failed to compress bundle: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress bundle: %w", err)
+	}
+
+	env := &envelope{
+		Format:  format,
+		Version: formatVersion,
+	}
+
+	var key []byte
+	if len(recipients) > 0 {
+		env.Mode = ModeRecipient
+		key = make([]byte, dataKeyLen)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+
+		for _, recipient := range recipients {
+			pub, err := decodeKey(recipient, recipientPrefix)
+			if err != nil {
+				return nil, err
+			}
+
+			sealedKey, err := box.SealAnonymous(nil, key, pub, rand.Reader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to seal data key: %w", err)
+			}
+
+			env.Recipients = append(
+				env.Recipients,
+				recipientKey{
+					Recipient: recipient,
+					SealedKey: sealedKey,
+				},
+			)
+		}
+	} else {
+		env.Mode = ModePassphrase
+		env.Salt = make([]byte, saltLen)
+		if _, err := io.ReadFull(rand.Reader, env.Salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+
+		key, err = crypto.NewAesKeyFromPassphraseWithSalt([]byte(passphrase), env.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate new AES key: %w", err)
+		}
+	}
+
+	env.Ciphertext, err = crypto.EncryptWithAesKey(bb.Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+
+	out, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize bundle envelope: %w", err)
+	}
+
+	return out, nil
+}
+
+// Mode returns encryption mode of a sealed bundle
+func Mode(data []byte) (string, error) {
+	env, err := decodeEnvelope(data)
+	if err != nil {
+		return "", err
+	}
+
+	return env.Mode, nil
+}
+
+// Open decrypts a sealed bundle using passphrase or identity
+// depending on how it was sealed
+func Open(data []byte, passphrase, identity string) (*Bundle, error) {
+	env, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	switch env.Mode {
+	case ModePassphrase:
+		key, err = crypto.NewAesKeyFromPassphraseWithSalt([]byte(passphrase), env.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate new AES key: %w", err)
+		}
+	case ModeRecipient:
+		if len(identity) == 0 {
+			return nil, fmt.Errorf("bundle is encrypted for recipients, identity is required")
+		}
+
+		prv, err := decodeKey(identity, identityPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		pub, err := publicKey(prv)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, recipient := range env.Recipients {
+			if k, ok := box.OpenAnonymous(nil, recipient.SealedKey, pub, prv); ok {
+				key = k
+				break
+			}
+		}
+
+		if key == nil {
+			return nil, fmt.Errorf("bundle is not encrypted for this identity")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported bundle encryption mode %s", env.Mode)
+	}
+
+	compressed, err := crypto.DecryptWithAesKey(env.Ciphertext, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt bundle: %w", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress bundle: %w", err)
+	}
+	defer zr.Close()
+
+	b := &Bundle{}
+	if err := json.NewDecoder(zr).Decode(b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+
+	return b, nil
+}
+
+func decodeEnvelope(data []byte) (*envelope, error) {
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle envelope: %w", err)
+	}
+
+	if env.Format != format {
+		return nil, fmt.Errorf("input is not a %s", format)
+	}
+
+	if env.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", env.Version)
+	}
+
+	return env, nil
+}
+
+func decodeKey(input, prefix string) (*[32]byte, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, prefix) {
+		return nil, fmt.Errorf("invalid key, expected prefix %s", prefix)
+	}
+
+	b, err := base58.Decode(strings.TrimPrefix(input, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to base58 decode key: %w", err)
+	}
+
+	if len(b) != 32 {
+		return nil, fmt.Errorf("invalid key length %d", len(b))
+	}
+
+	key := new([32]byte)
+	copy(key[:], b)
+
+	return key, nil
+}
+
+func publicKey(prv *[32]byte) (*[32]byte, error) {
+	b, err := curve25519.X25519(prv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	pub := new([32]byte)
+	copy(pub[:], b)
+
+	return pub, nil
+}
@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Project:    "test-project",
+		CreateTime: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		Secrets: []Secret{
+			{
+				Name:   "db-password",
+				Labels: map[string]string{"team": "core"},
+				Versions: []Version{
+					{Version: "1", Payload: []byte("s3cr3t")},
+				},
+			},
+		},
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	identity, recipient, err := NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherIdentity, _, err := NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		passphrase   string
+		recipients   []string
+		openPass     string
+		openIdentity string
+		wantMode     string
+		wantErr      bool
+	}{
+		{
+			name:       "passphrase",
+			passphrase: "vX9#qL2@mN7$wR4!",
+			openPass:   "vX9#qL2@mN7$wR4!",
+			wantMode:   ModePassphrase,
+		},
+		{
+			name:       "wrong passphrase",
+			passphrase: "vX9#qL2@mN7$wR4!",
+			openPass:   "wrong passphrase",
+			wantMode:   ModePassphrase,
+			wantErr:    true,
+		},
+		{
+			name:         "recipient",
+			recipients:   []string{recipient},
+			openIdentity: identity,
+			wantMode:     ModeRecipient,
+		},
+		{
+			name:         "other identity",
+			recipients:   []string{recipient},
+			openIdentity: otherIdentity,
+			wantMode:     ModeRecipient,
+			wantErr:      true,
+		},
+		{
+			name:       "missing identity",
+			recipients: []string{recipient},
+			wantMode:   ModeRecipient,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := Seal(testBundle(), tt.passphrase, tt.recipients)
+		if err != nil {
+			t.Fatalf("%s: Seal() error = %v", tt.name, err)
+		}
+
+		if bytes.Contains(data, []byte("s3cr3t")) {
+			t.Errorf("%s: sealed bundle contains plaintext payload", tt.name)
+		}
+
+		if mode, err := Mode(data); err != nil || mode != tt.wantMode {
+			t.Errorf("%s: Mode() = %q, %v, want %q", tt.name, mode, err, tt.wantMode)
+		}
+
+		b, err := Open(data, tt.openPass, tt.openIdentity)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Open() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		if b.Project != "test-project" || len(b.Secrets) != 1 ||
+			string(b.Secrets[0].Versions[0].Payload) != "s3cr3t" {
+			t.Errorf("%s: Open() = %+v, want original bundle", tt.name, b)
+		}
+	}
+}
+
+func TestRecipient(t *testing.T) {
+	identity, recipient, err := NewIdentity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := Recipient(identity); err != nil || got != recipient {
+		t.Errorf("Recipient() = %q, %v, want %q", got, err, recipient)
+	}
+
+	for _, input := range []string{recipient, "MKSECRET-IDENTITY-abc", "garbage"} {
+		if _, err := Recipient(input); err == nil {
+			t.Errorf("Recipient(%q) did not fail", input)
+		}
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	for _, input := range []string{
+		`not json`,
+		`{"format":"other","version":1}`,
+		`{"format":"mksecret-bundle","version":2}`,
+		`{"format":"mksecret-bundle","version":1,"mode":"other"}`,
+	} {
+		if _, err := Open([]byte(input), "", ""); err == nil {
+			t.Errorf("Open(%q) did not fail", input)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Sequence returns OSC 52 escape sequence that sets system clipboard
+// of the terminal to data. Sequence is wrapped for pass through when
+// running inside tmux or screen, which otherwise swallow it.
+func Sequence(data []byte) string {
+	seq := fmt.Sprintf("\x1b]52;c;%s\x07", base64.StdEncoding.EncodeToString(data))
+
+	switch {
+	case len(os.Getenv("TMUX")) > 0:
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+
+	return seq
+}
+
+// Copy sets clipboard of terminal connected to w to data. It works
+// over SSH and needs no display server, however, terminal must allow
+// clipboard access via OSC 52.
+func Copy(w io.Writer, data []byte) error {
+	if _, err := io.WriteString(w, Sequence(data)); err != nil {
+		return fmt.Errorf("failed to write clipboard sequence: %w", err)
+	}
+
+	return nil
+}
+
+// Clear overwrites clipboard of terminal connected to w
+func Clear(w io.Writer) error {
+	return Copy(w, nil)
+}
+
+// Terminal opens controlling terminal so that escape sequences reach
+// it even when stdout is redirected
+func Terminal() (io.WriteCloser, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal: %w", err)
+	}
+
+	return tty, nil
+}
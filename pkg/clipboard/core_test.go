@@ -0,0 +1,64 @@
+package clipboard
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name string
+		tmux string
+		term string
+		data string
+		want string
+	}{
+		{
+			name: "plain",
+			term: "xterm-256color",
+			data: "s3cr3t",
+			want: "\x1b]52;c;czNjcjN0\x07",
+		},
+		{
+			name: "tmux",
+			tmux: "/tmp/tmux-1000/default,1,0",
+			term: "screen-256color",
+			data: "s3cr3t",
+			want: "\x1bPtmux;\x1b\x1b]52;c;czNjcjN0\x07\x1b\\",
+		},
+		{
+			name: "screen",
+			term: "screen",
+			data: "s3cr3t",
+			want: "\x1bP\x1b]52;c;czNjcjN0\x07\x1b\\",
+		},
+		{
+			name: "clear",
+			term: "xterm",
+			want: "\x1b]52;c;\x07",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TMUX", tt.tmux)
+		t.Setenv("TERM", tt.term)
+
+		if got := Sequence([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: Sequence() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClear(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm")
+
+	var b bytes.Buffer
+	if err := Clear(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "\x1b]52;c;\x07"; b.String() != want {
+		t.Errorf("Clear() wrote %q, want %q", b.String(), want)
+	}
+}
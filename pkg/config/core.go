@@ -0,0 +1,387 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// BackendGcp is Google Secret Manager, the only supported backend
+const BackendGcp = "gcp"
+
+// Context is a named profile of settings. Settings of the context in
+// use override top level settings of the config file.
+type Context struct {
+	Project                string   `json:"google-project-id,omitempty" yaml:"google-project-id,omitempty" mapstructure:"google-project-id"`
+	ApplicationCredentials string   `json:"google-application-credentials,omitempty" yaml:"google-application-credentials,omitempty" mapstructure:"google-application-credentials"`
+	Backend                string   `json:"backend,omitempty" yaml:"backend,omitempty" mapstructure:"backend"`
+	Encrypt                *bool    `json:"encrypt,omitempty" yaml:"encrypt,omitempty" mapstructure:"encrypt"`
+	Replication            []string `json:"replication,omitempty" yaml:"replication,omitempty" mapstructure:"replication"`
+	OutputFormat           string   `json:"output-format,omitempty" yaml:"output-format,omitempty" mapstructure:"output-format"`
+}
+
+// Keys lists settings of a context
+var Keys = []string{
+	flags.GoogleProjectID,
+	flags.GoogleApplicationCredentials,
+	flags.Backend,
+	flags.Encrypt,
+	flags.Replication,
+	flags.OutputFormat,
+}
+
+// Settings returns settings of context that are set keyed by
+// config key
+func (c *Context) Settings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	if len(c.Project) > 0 {
+		settings[flags.GoogleProjectID] = c.Project
+	}
+	if len(c.ApplicationCredentials) > 0 {
+		settings[flags.GoogleApplicationCredentials] = c.ApplicationCredentials
+	}
+	if len(c.Backend) > 0 {
+		settings[flags.Backend] = c.Backend
+	}
+	if c.Encrypt != nil {
+		settings[flags.Encrypt] = *c.Encrypt
+	}
+	if len(c.Replication) > 0 {
+		settings[flags.Replication] = c.Replication
+	}
+	if len(c.OutputFormat) > 0 {
+		settings[flags.OutputFormat] = c.OutputFormat
+	}
+
+	return settings
+}
+
+// Validate checks that settings of context are supported
+func (c *Context) Validate() error {
+	if len(c.Backend) > 0 && c.Backend != BackendGcp {
+		return fmt.Errorf("backend %s is not supported, supported backend is %s", c.Backend, BackendGcp)
+	}
+
+	switch c.OutputFormat {
+	case "",
+		flags.OutputFormatNative,
+		flags.OutputFormatJson,
+		flags.OutputFormatYaml,
+		flags.OutputFormatTable:
+	default:
+		return fmt.Errorf("invalid output format %s", c.OutputFormat)
+	}
+
+	return nil
+}
+
+// ValidateName checks that name of a context is a DNS1123 label.
+// Config keys are case-insensitive, so names need to be lower case.
+func ValidateName(name string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("invalid context name %s, need DNS1123Label format: %v", name, errs)
+	}
+
+	return nil
+}
+
+// DefaultFile returns path of config file in home directory
+func DefaultFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".mksecret.yaml"), nil
+}
+
+// File is a yaml config file that is edited in place preserving
+// comments, order of keys and settings other than contexts
+type File struct {
+	name string
+	mode fs.FileMode
+	doc  *yaml.Node
+}
+
+// Open reads a config file. A missing file is treated as empty and
+// is created when saved.
+func Open(name string) (*File, error) {
+	switch ext := filepath.Ext(name); ext {
+	case ".yaml", ".yml":
+	default:
+		return nil, fmt.Errorf("config file %s is not a yaml file and cannot be edited", name)
+	}
+
+	f := &File{
+		name: name,
+		mode: 0600,
+		doc:  &yaml.Node{},
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if fi, err := os.Stat(name); err == nil {
+		f.mode = fi.Mode().Perm()
+	}
+
+	if err := yaml.Unmarshal(b, f.doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if f.doc.Kind != 0 && (len(f.doc.Content) == 0 || f.doc.Content[0].Kind != yaml.MappingNode) {
+		return nil, fmt.Errorf("config file %s is not a yaml map", name)
+	}
+
+	return f, nil
+}
+
+// Name returns path of the config file
+func (f *File) Name() string {
+	return f.name
+}
+
+// root returns top level map of the config file creating it if needed
+func (f *File) root() *yaml.Node {
+	if f.doc.Kind == 0 {
+		f.doc.Kind = yaml.DocumentNode
+		f.doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	return f.doc.Content[0]
+}
+
+// CurrentContext returns name of the context in use
+func (f *File) CurrentContext() string {
+	if value := lookup(f.root(), flags.CurrentContext); value != nil {
+		return value.Value
+	}
+
+	return ""
+}
+
+// Contexts decodes all contexts in config file
+func (f *File) Contexts() (map[string]Context, error) {
+	contexts := make(map[string]Context)
+
+	node := lookup(f.root(), flags.Contexts)
+	if node == nil {
+		return contexts, nil
+	}
+
+	if err := node.Decode(&contexts); err != nil {
+		return nil, fmt.Errorf("failed to parse contexts in config file: %w", err)
+	}
+
+	return contexts, nil
+}
+
+// UseContext sets current context, which must exist
+func (f *File) UseContext(name string) error {
+	contexts, err := f.Contexts()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := contexts[name]; !ok {
+		return fmt.Errorf("context %s not found in config file %s", name, f.name)
+	}
+
+	set(f.root(), flags.CurrentContext, scalar(name))
+	return nil
+}
+
+// Set sets a setting of named context creating the context if it does
+// not exist. An empty value removes the setting.
+func (f *File) Set(name, key, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	var node *yaml.Node
+	switch key {
+	case flags.GoogleProjectID, flags.GoogleApplicationCredentials:
+		node = scalar(value)
+	case flags.Backend:
+		if err := (&Context{Backend: value}).Validate(); err != nil {
+			return err
+		}
+		node = scalar(value)
+	case flags.OutputFormat:
+		if err := (&Context{OutputFormat: value}).Validate(); err != nil {
+			return err
+		}
+		node = scalar(value)
+	case flags.Encrypt:
+		if len(value) > 0 {
+			encrypt, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %s for %s, need true or false: %w", value, key, err)
+			}
+			node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(encrypt)}
+		}
+	case flags.Replication:
+		// comma separated list of locations, empty for automatic replication
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, location := range strings.Split(value, ",") {
+			if location = strings.TrimSpace(location); len(location) > 0 {
+				node.Content = append(node.Content, scalar(location))
+			}
+		}
+		if len(node.Content) == 0 {
+			node = nil
+		}
+	default:
+		return fmt.Errorf("invalid key %s, valid keys are %v", key, Keys)
+	}
+
+	if node != nil && node.Kind == yaml.ScalarNode && len(node.Value) == 0 {
+		node = nil
+	}
+
+	contexts := lookup(f.root(), flags.Contexts)
+	if contexts == nil {
+		contexts = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		set(f.root(), flags.Contexts, contexts)
+	}
+
+	context := lookup(contexts, name)
+	if context == nil || context.Kind != yaml.MappingNode {
+		context = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		set(contexts, name, context)
+	}
+
+	if node == nil {
+		remove(context, key)
+		return nil
+	}
+
+	set(context, key, node)
+	return nil
+}
+
+// Bytes returns yaml serialization of config file
+func (f *File) Bytes() ([]byte, error) {
+	bb := new(bytes.Buffer)
+	if f.doc.Kind == 0 {
+		return bb.Bytes(), nil
+	}
+
+	enc := yaml.NewEncoder(bb)
+	enc.SetIndent(2)
+	if err := enc.Encode(f.doc); err != nil {
+		return nil, fmt.Errorf("failed to serialize config file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to serialize config file: %w", err)
+	}
+
+	return bb.Bytes(), nil
+}
+
+// Save writes config file atomically via a temp file in the same
+// dir so that an interrupted write does not leave a partial file
+func (f *File) Save() error {
+	b, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.name), "."+filepath.Base(f.name)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(f.mode); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to set config file permissions: %w", err)
+	}
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.name); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+
+	return nil
+}
+
+// SortedNames returns names of contexts in sorted order
+func SortedNames(contexts map[string]Context) []string {
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// lookup returns value node of key in a map node
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// set sets value of key in a map node keeping position of an
+// existing key
+func set(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			// keep comments attached to the old value
+			value.HeadComment = m.Content[i+1].HeadComment
+			value.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = value
+			return
+		}
+	}
+
+	m.Content = append(m.Content, scalar(key), value)
+}
+
+// remove removes key from a map node
+func remove(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/flags"
+)
+
+func TestContextValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		context Context
+		wantErr bool
+	}{
+		{name: "empty", context: Context{}},
+		{name: "valid", context: Context{Backend: BackendGcp, OutputFormat: flags.OutputFormatJson}},
+		{name: "invalid backend", context: Context{Backend: "vault"}, wantErr: true},
+		{name: "invalid output format", context: Context{OutputFormat: "xml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.context.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	encrypt := false
+	c := &Context{
+		Project:     "test-project",
+		Encrypt:     &encrypt,
+		Replication: []string{"us-east1"},
+	}
+
+	want := map[string]interface{}{
+		flags.GoogleProjectID: "test-project",
+		flags.Encrypt:         false,
+		flags.Replication:     []string{"us-east1"},
+	}
+
+	if got := c.Settings(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Settings() = %v, want %v", got, want)
+	}
+}
+
+func TestFileSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "project", key: flags.GoogleProjectID, value: "p1", want: "google-project-id: p1"},
+		{name: "encrypt", key: flags.Encrypt, value: "true", want: "encrypt: true"},
+		{name: "replication", key: flags.Replication, value: "us-east1, us-west1", want: "- us-east1\n      - us-west1"},
+		{name: "output format", key: flags.OutputFormat, value: "yaml", want: "output-format: yaml"},
+		{name: "invalid encrypt", key: flags.Encrypt, value: "maybe", wantErr: true},
+		{name: "invalid backend", key: flags.Backend, value: "vault", wantErr: true},
+		{name: "invalid key", key: "color", value: "red", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		f, err := Open(filepath.Join(t.TempDir(), "config.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = f.Set("dev", tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Set() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if tt.wantErr {
+			continue
+		}
+
+		b, err := f.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(string(b), tt.want) {
+			t.Errorf("%s: config file\n%s\ndoes not contain %q", tt.name, b, tt.want)
+		}
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(name, []byte(`# top level comment
+output-format: table
+contexts:
+  dev:
+    google-project-id: dev-project # dev
+`), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.UseContext("prod"); err == nil {
+		t.Errorf("UseContext() of missing context did not fail")
+	}
+
+	if err := f.Set("dev", flags.GoogleProjectID, "other-project"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Set("prod", flags.GoogleProjectID, "prod-project"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.UseContext("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0640 {
+		t.Errorf("config file mode = %v, want 0640", fi.Mode().Perm())
+	}
+
+	f, err = Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.CurrentContext(); got != "prod" {
+		t.Errorf("CurrentContext() = %q, want prod", got)
+	}
+
+	contexts, err := f.Contexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SortedNames(contexts); !reflect.DeepEqual(got, []string{"dev", "prod"}) {
+		t.Errorf("SortedNames() = %q, want [dev prod]", got)
+	}
+
+	if contexts["dev"].Project != "other-project" {
+		t.Errorf("dev project = %q, want other-project", contexts["dev"].Project)
+	}
+
+	b, err := f.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"# top level comment", "output-format: table", "# dev"} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("config file\n%s\ndoes not preserve %q", b, want)
+		}
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Open(filepath.Join(dir, "config.json")); err == nil {
+		t.Errorf("Open() of non yaml file did not fail")
+	}
+
+	name := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(name, []byte("- a\n- b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(name); err == nil {
+		t.Errorf("Open() of yaml list did not fail")
+	}
+}
@@ -23,7 +23,16 @@ func NewAesKeyFromPassphrase(passphrase []byte) ([]byte, error) {
 	}
 
 	salt := md5.Sum(passphrase)
-	key := pbkdf2.Key(passphrase, salt[:], 4096, 32, sha256.New)
+	return NewAesKeyFromPassphraseWithSalt(passphrase, salt[:])
+}
+
+// NewAesKeyFromPassphraseWithSalt generates new AES key using input key and salt
+func NewAesKeyFromPassphraseWithSalt(passphrase, salt []byte) ([]byte, error) {
+	if len(passphrase) < minPassphraseLen {
+		return nil, fmt.Errorf("passphrase length needs to be at least 8")
+	}
+
+	key := pbkdf2.Key(passphrase, salt, 4096, 32, sha256.New)
 	return key, nil
 }
 
@@ -0,0 +1,325 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const contextLines = 3
+
+// FieldChange is a change of a field value in a structured document
+type FieldChange struct {
+	Path string `json:"path" yaml:"path"`
+	Old  string `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// op is an edit operation on a line
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified writes a unified diff of two texts with three lines of context.
+// Nothing is written when texts are identical.
+func Unified(w io.Writer, oldName, newName, oldText, newText string) error {
+	ops := lineOps(splitLines(oldText), splitLines(newText))
+
+	changed := false
+	for _, o := range ops {
+		if o.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	// index of ops into old and new lines before each op
+	oldIdx := make([]int, len(ops)+1)
+	newIdx := make([]int, len(ops)+1)
+	for i, o := range ops {
+		oldIdx[i+1], newIdx[i+1] = oldIdx[i], newIdx[i]
+		if o.kind != '+' {
+			oldIdx[i+1]++
+		}
+		if o.kind != '-' {
+			newIdx[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		// extend hunk while changes are within twice the context
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*contextLines {
+				break
+			}
+		}
+
+		end += contextLines
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		oldCount := oldIdx[end] - oldIdx[start]
+		newCount := newIdx[end] - newIdx[start]
+		if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n",
+			hunkRange(oldIdx[start], oldCount), hunkRange(newIdx[start], newCount)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+
+		for _, o := range ops[start:end] {
+			if _, err := fmt.Fprintf(w, "%c%s\n", o.kind, o.line); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+
+		i = end
+	}
+
+	return nil
+}
+
+// Fields compares two JSON documents field by field and returns changes
+// sorted by path. Nested fields are addressed using dot notation and
+// array elements using index notation, for instance db.hosts[0].
+func Fields(oldDoc, newDoc []byte) ([]FieldChange, error) {
+	var oldValue, newValue interface{}
+	if err := json.Unmarshal(oldDoc, &oldValue); err != nil {
+		return nil, fmt.Errorf("failed to parse old json: %w", err)
+	}
+
+	if err := json.Unmarshal(newDoc, &newValue); err != nil {
+		return nil, fmt.Errorf("failed to parse new json: %w", err)
+	}
+
+	oldFields := make(map[string]string)
+	newFields := make(map[string]string)
+	flatten("", oldValue, oldFields)
+	flatten("", newValue, newFields)
+
+	changes := make([]FieldChange, 0, len(oldFields))
+	for path, value := range oldFields {
+		if newFieldValue, ok := newFields[path]; !ok || newFieldValue != value {
+			changes = append(changes, FieldChange{Path: path, Old: value, New: newFields[path]})
+		}
+	}
+
+	for path, value := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			changes = append(changes, FieldChange{Path: path, New: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+// IsJson reports if input is a JSON object or array
+func IsJson(input []byte) bool {
+	var value interface{}
+	if err := json.Unmarshal(input, &value); err != nil {
+		return false
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func flatten(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			out[rootPath(prefix)] = "{}"
+		}
+		for key, child := range v {
+			path := key
+			if len(prefix) > 0 {
+				path = prefix + "." + key
+			}
+			flatten(path, child, out)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			out[rootPath(prefix)] = "[]"
+		}
+		for i, child := range v {
+			flatten(prefix+"["+strconv.Itoa(i)+"]", child, out)
+		}
+	default:
+		b, _ := json.Marshal(v)
+		out[rootPath(prefix)] = string(b)
+	}
+}
+
+func rootPath(path string) string {
+	if len(path) == 0 {
+		return "."
+	}
+	return path
+}
+
+func splitLines(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return strconv.Itoa(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// lineOps computes edit operations using longest common subsequence.
+// Common prefix and suffix are matched directly and the rest is split
+// recursively as per Hirschberg's algorithm, so memory grows linearly
+// with the number of lines.
+func lineOps(a, b []string) []op {
+	ops := make([]op, 0, len(a)+len(b))
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, op{kind: ' ', line: a[prefix]})
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops = splitOps(ops, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{kind: ' ', line: line})
+	}
+
+	return ops
+}
+
+// splitOps appends edit operations of a and b to ops by splitting a in
+// half and b where the longest common subsequence crosses that half
+func splitOps(ops []op, a, b []string) []op {
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			ops = append(ops, op{kind: '+', line: line})
+		}
+		return ops
+	case len(b) == 0:
+		for _, line := range a {
+			ops = append(ops, op{kind: '-', line: line})
+		}
+		return ops
+	case len(a) == 1:
+		for j, line := range b {
+			if line == a[0] {
+				for _, added := range b[:j] {
+					ops = append(ops, op{kind: '+', line: added})
+				}
+				ops = append(ops, op{kind: ' ', line: line})
+				for _, added := range b[j+1:] {
+					ops = append(ops, op{kind: '+', line: added})
+				}
+				return ops
+			}
+		}
+
+		ops = append(ops, op{kind: '-', line: a[0]})
+		for _, line := range b {
+			ops = append(ops, op{kind: '+', line: line})
+		}
+		return ops
+	}
+
+	mid := len(a) / 2
+	forward := lcsLengths(a[:mid], b, false)
+	backward := lcsLengths(a[mid:], b, true)
+
+	split, best := 0, -1
+	for j := 0; j <= len(b); j++ {
+		if l := forward[j] + backward[len(b)-j]; l > best {
+			split, best = j, l
+		}
+	}
+
+	ops = splitOps(ops, a[:mid], b[:split])
+	return splitOps(ops, a[mid:], b[split:])
+}
+
+// lcsLengths returns lengths of longest common subsequence of a and
+// each prefix of b, or of each suffix of b when reversed, in which
+// case both are compared from their ends
+func lcsLengths(a, b []string, reversed bool) []int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for i := range a {
+		ai := a[i]
+		if reversed {
+			ai = a[len(a)-1-i]
+		}
+
+		for j := range b {
+			bj := b[j]
+			if reversed {
+				bj = b[len(b)-1-j]
+			}
+
+			switch {
+			case ai == bj:
+				curr[j+1] = prev[j] + 1
+			case prev[j+1] >= curr[j]:
+				curr[j+1] = prev[j+1]
+			default:
+				curr[j+1] = curr[j]
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev
+}
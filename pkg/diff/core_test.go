@@ -0,0 +1,203 @@
+package diff
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		want    string
+	}{
+		{
+			name:    "identical",
+			oldText: "a\nb\n",
+			newText: "a\nb\n",
+			want:    "",
+		},
+		{
+			name:    "changed line",
+			oldText: "a\nb\nc\n",
+			newText: "a\nB\nc\n",
+			want:    "--- v1\n+++ v2\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "added to empty",
+			oldText: "",
+			newText: "a\n",
+			want:    "--- v1\n+++ v2\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:    "separate hunks",
+			oldText: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			newText: "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			want: "--- v1\n+++ v2\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := Unified(&b, "v1", "v2", tt.oldText, tt.newText); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if b.String() != tt.want {
+			t.Errorf("%s: Unified() =\n%s\nwant\n%s", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldDoc  string
+		newDoc  string
+		want    []FieldChange
+		wantErr bool
+	}{
+		{
+			name:   "identical",
+			oldDoc: `{"a":1}`,
+			newDoc: `{ "a": 1 }`,
+			want:   []FieldChange{},
+		},
+		{
+			name:   "nested changes",
+			oldDoc: `{"db":{"hosts":["h1","h2"],"port":5432},"user":"admin"}`,
+			newDoc: `{"db":{"hosts":["h1"],"port":5433},"token":"t"}`,
+			want: []FieldChange{
+				{Path: "db.hosts[1]", Old: `"h2"`},
+				{Path: "db.port", Old: "5432", New: "5433"},
+				{Path: "token", New: `"t"`},
+				{Path: "user", Old: `"admin"`},
+			},
+		},
+		{
+			name:   "empty containers",
+			oldDoc: `{"a":{}}`,
+			newDoc: `{"a":[]}`,
+			want:   []FieldChange{{Path: "a", Old: "{}", New: "[]"}},
+		},
+		{
+			name:    "invalid",
+			oldDoc:  `{`,
+			newDoc:  `{}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Fields([]byte(tt.oldDoc), []byte(tt.newDoc))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Fields() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Fields() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsJson(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: `{"a":1}`, want: true},
+		{input: `[1,2]`, want: true},
+		{input: `"string"`, want: false},
+		{input: `42`, want: false},
+		{input: `not json`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsJson([]byte(tt.input)); got != tt.want {
+			t.Errorf("IsJson(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLineOps(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	lines := func(n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return out
+	}
+
+	// lcs computes length of longest common subsequence using full table
+	lcs := func(a, b []string) int {
+		table := make([][]int, len(a)+1)
+		for i := range table {
+			table[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				switch {
+				case a[i] == b[j]:
+					table[i][j] = table[i+1][j+1] + 1
+				case table[i+1][j] > table[i][j+1]:
+					table[i][j] = table[i+1][j]
+				default:
+					table[i][j] = table[i][j+1]
+				}
+			}
+		}
+		return table[0][0]
+	}
+
+	for n := 0; n < 200; n++ {
+		a, b := lines(rnd.Intn(20)), lines(rnd.Intn(20))
+
+		var gotA, gotB []string
+		common := 0
+		for _, o := range lineOps(a, b) {
+			if o.kind != '+' {
+				gotA = append(gotA, o.line)
+			}
+			if o.kind != '-' {
+				gotB = append(gotB, o.line)
+			}
+			if o.kind == ' ' {
+				common++
+			}
+		}
+
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("lineOps(%q, %q) does not reconstruct inputs: %q, %q", a, b, gotA, gotB)
+		}
+
+		if want := lcs(a, b); common != want {
+			t.Fatalf("lineOps(%q, %q) keeps %d lines, want %d", a, b, common, want)
+		}
+	}
+}
+
+func TestUnifiedLarge(t *testing.T) {
+	oldLines := make([]string, 5000)
+	newLines := make([]string, 5000)
+	for i := range oldLines {
+		oldLines[i] = "old " + strconv.Itoa(i)
+		newLines[i] = "new " + strconv.Itoa(i)
+	}
+
+	var b bytes.Buffer
+	if err := Unified(&b, "v1", "v2", strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(b.String(), "--- v1\n+++ v2\n@@ -1,5000 +1,5000 @@\n") {
+		t.Errorf("unexpected diff header %q", b.String()[:40])
+	}
+}
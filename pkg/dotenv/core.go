@@ -0,0 +1,205 @@
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Entry is a key value pair of a dotenv file
+type Entry struct {
+	Key   string
+	Value string
+}
+
+var (
+	keyRegexp         = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	invalidNameRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Parse parses dotenv formatted input preserving the order of entries.
+// Blank lines, comments and an optional export prefix are allowed.
+// Single quoted values are taken literally, double quoted values
+// can span multiple lines and support \n, \r, \t, \", \\ and \$ escapes,
+// and unquoted values are trimmed with inline comments removed.
+func Parse(r io.Reader) ([]Entry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	entries := make([]Entry, 0, 32)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing = separator", lineNum)
+		}
+
+		key = strings.TrimSpace(key)
+		if !keyRegexp.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNum, key)
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quoted value", lineNum)
+			}
+			value = value[1 : end+1]
+		case strings.HasPrefix(value, `"`):
+			raw := value[1:]
+			for {
+				end := closingQuote(raw)
+				if end >= 0 {
+					raw = raw[:end]
+					break
+				}
+				if !scanner.Scan() {
+					return nil, fmt.Errorf("line %d: unterminated double quoted value", lineNum)
+				}
+				lineNum++
+				raw = raw + "\n" + scanner.Text()
+			}
+			value = unescape(raw)
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+
+		entries = append(entries, Entry{Key: key, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return entries, nil
+}
+
+// closingQuote returns index of first unescaped double quote or -1
+func closingQuote(s string) int {
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// unescape resolves escape sequences within a double quoted value
+func unescape(s string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range s {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+				continue
+			}
+			sb.WriteRune(r)
+			continue
+		}
+
+		escaped = false
+		switch r {
+		case 'n':
+			sb.WriteRune('\n')
+		case 'r':
+			sb.WriteRune('\r')
+		case 't':
+			sb.WriteRune('\t')
+		case '"', '\\', '$', '`':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		}
+	}
+
+	if escaped {
+		sb.WriteRune('\\')
+	}
+
+	return sb.String()
+}
+
+// Quote double quotes a value escaping chars that would otherwise
+// be interpreted by dotenv parsers or shells
+func Quote(value string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"`", "\\`",
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+
+	return `"` + r.Replace(value) + `"`
+}
+
+// Format writes entries in dotenv format with all values double quoted
+func Format(w io.Writer, entries []Entry) error {
+	for _, entry := range entries {
+		if !keyRegexp.MatchString(entry.Key) {
+			return fmt.Errorf("invalid key %q", entry.Key)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", entry.Key, Quote(entry.Value)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// KeyToName normalizes a dotenv key to a secret name
+// in DNS1123 label format
+func KeyToName(key string) (string, error) {
+	name := invalidNameRegexp.ReplaceAllString(strings.ToLower(key), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > validation.DNS1123LabelMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123LabelMaxLength], "-")
+	}
+
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return "", fmt.Errorf("key %s cannot be normalized to DNS1123Label format: %v", key, errs)
+	}
+
+	return name, nil
+}
+
+// NameToKey converts a secret name to a dotenv key. Key is prefixed
+// with an underscore if the name starts with a digit.
+func NameToKey(name string) string {
+	key := strings.ToUpper(strings.NewReplacer("-", "_", "/", "_").Replace(name))
+	if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+
+	return key
+}
@@ -0,0 +1,123 @@
+package dotenv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			name:  "unquoted",
+			input: "# comment\n\nDB_HOST = localhost\nexport DB_PORT=5432 # port\n",
+			want:  []Entry{{"DB_HOST", "localhost"}, {"DB_PORT", "5432"}},
+		},
+		{
+			name:  "single quoted",
+			input: `TOKEN='a\nb $HOME'`,
+			want:  []Entry{{"TOKEN", `a\nb $HOME`}},
+		},
+		{
+			name:  "double quoted escapes",
+			input: `TOKEN="a\nb \"c\" \$HOME \\"`,
+			want:  []Entry{{"TOKEN", "a\nb \"c\" $HOME \\"}},
+		},
+		{
+			name:  "double quoted multiline",
+			input: "KEY=\"line1\nline2\"\nNEXT=x",
+			want:  []Entry{{"KEY", "line1\nline2"}, {"NEXT", "x"}},
+		},
+		{
+			name:  "empty value",
+			input: "EMPTY=",
+			want:  []Entry{{"EMPTY", ""}},
+		},
+		{name: "missing separator", input: "KEY", wantErr: true},
+		{name: "invalid key", input: "1KEY=x", wantErr: true},
+		{name: "unterminated single quote", input: "KEY='x", wantErr: true},
+		{name: "unterminated double quote", input: "KEY=\"x\nY=z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(strings.NewReader(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Parse() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Parse() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	entries := []Entry{
+		{"PLAIN", "value"},
+		{"SPECIAL", "a \"quoted\" $VAR `cmd` \\ end"},
+		{"MULTILINE", "line1\nline2\r\n\tindented"},
+	}
+
+	var b bytes.Buffer
+	if err := Format(&b, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Parse(Format()) = %q, want %q", got, entries)
+	}
+}
+
+func TestKeyToName(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "DB_PASSWORD", want: "db-password"},
+		{key: "_API__KEY_", want: "api-key"},
+		{key: "app.token", want: "app-token"},
+		{key: "___", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := KeyToName(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("KeyToName(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("KeyToName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestNameToKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "db-password", want: "DB_PASSWORD"},
+		{name: "team/app/db-password", want: "TEAM_APP_DB_PASSWORD"},
+		{name: "1password", want: "_1PASSWORD"},
+	}
+
+	for _, tt := range tests {
+		if got := NameToKey(tt.name); got != tt.want {
+			t.Errorf("NameToKey(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
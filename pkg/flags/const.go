@@ -6,13 +6,86 @@ const (
 )
 
 const (
-	Name         = "name"
-	Version      = "version"
-	Force        = "force"
-	Encrypt      = "encrypt"
-	OutputFormat = "output-format"
-	Passphrase   = "passphrase"
-	NoPrompt     = "no-prompt"
+	Name              = "name"
+	Version           = "version"
+	Force             = "force"
+	Encrypt           = "encrypt"
+	OutputFormat      = "output-format"
+	Passphrase        = "passphrase"
+	PassphraseFile    = "passphrase-file"
+	PassphraseEnv     = "passphrase-env"
+	PassphraseFd      = "passphrase-fd"
+	PassphraseCommand = "passphrase-command"
+	NoPrompt          = "no-prompt"
+	Env               = "env"
+	File              = "file"
+	Out               = "out"
+	Selector          = "selector"
+	Key               = "key"
+	Label             = "label"
+	Namespace         = "namespace"
+	Type              = "type"
+	Kind              = "kind"
+	SecretStore       = "secret-store"
+	SecretStoreKind   = "secret-store-kind"
+	RefreshInterval   = "refresh-interval"
+	Prune             = "prune"
+	Recipient         = "recipient"
+	Identity          = "identity"
+	Conflict          = "conflict"
+	FromProject       = "from-project"
+	ToProject         = "to-project"
+	ToName            = "to-name"
+	AllVersions       = "all-versions"
+	CopyTopics        = "copy-topics"
+	DryRun            = "dry-run"
+	Redacted          = "redacted"
+	Generate          = "generate"
+	Length            = "length"
+	Charset           = "charset"
+	ExcludeAmbiguous  = "exclude-ambiguous"
+	Words             = "words"
+	Separator         = "separator"
+	Language          = "language"
+	Derive            = "derive"
+	Bip39Passphrase   = "bip39-passphrase"
+	DerivationPath    = "path"
+	Network           = "network"
+	StoreAs           = "store-as"
+	Bits              = "bits"
+	Comment           = "comment"
+	CommonName        = "common-name"
+	Hosts             = "hosts"
+	ValidFor          = "valid-for"
+	Ca                = "ca"
+	IsCa              = "is-ca"
+	Within            = "within"
+	Socket            = "socket"
+	Timeout           = "timeout"
+	Clip              = "clip"
+	ClipTimeout       = "clip-timeout"
+	Tree              = "tree"
+	Context           = "context"
+)
+
+// config keys for passphrase policy
+const (
+	PassphraseMinLength   = "passphrase-min-length"
+	PassphraseMinEntropy  = "passphrase-min-entropy"
+	PassphraseBannedWords = "passphrase-banned-words"
+)
+
+// config keys for contexts
+const (
+	Contexts       = "contexts"
+	CurrentContext = "current-context"
+	Backend        = "backend"
+	Replication    = "replication"
+)
+
+// config keys for namespace defaults
+const (
+	Namespaces = "namespaces"
 )
 
 const (
@@ -0,0 +1,217 @@
+package generate
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+const (
+	KindPassword = "password"
+	KindHex      = "hex"
+	KindBase64   = "base64"
+	KindBase58   = "base58"
+	KindUuid     = "uuid"
+	KindDiceware = "diceware"
+
+	// KindBip39Words is an alias of KindDiceware
+	KindBip39Words = "bip39-words"
+)
+
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+const (
+	DefaultPasswordLength = 32
+	DefaultTokenBytes     = 32
+	DefaultWords          = 6
+	DefaultSeparator      = "-"
+)
+
+const ambiguous = "0O1lI|`'\""
+
+var classes = map[string]string{
+	ClassLower:  "abcdefghijklmnopqrstuvwxyz",
+	ClassUpper:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	ClassDigit:  "0123456789",
+	ClassSymbol: "!#$%&()*+,-./:;<=>?@[]^_{}~",
+}
+
+// Policy declares how a random value is generated. Length is the
+// number of characters for passwords and number of random bytes
+// for hex, base64 and base58 tokens. Words and Separator only apply
+// to diceware passphrases, which draw words from BIP39 english word list.
+type Policy struct {
+	Kind             string
+	Length           int
+	Classes          []string
+	ExcludeAmbiguous bool
+	Words            int
+	Separator        string
+}
+
+// Kinds returns supported kinds of generated values
+func Kinds() []string {
+	return []string{KindPassword, KindHex, KindBase64, KindBase58, KindUuid, KindDiceware}
+}
+
+// Classes returns supported password character classes
+func Classes() []string {
+	return []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}
+}
+
+// New generates a random value as per policy
+func New(policy *Policy) (string, error) {
+	switch policy.Kind {
+	case KindPassword, "":
+		return newPassword(policy)
+	case KindHex, KindBase64, KindBase58:
+		length := policy.Length
+		if length == 0 {
+			length = DefaultTokenBytes
+		}
+
+		if length < 0 {
+			return "", fmt.Errorf("invalid length %d", length)
+		}
+
+		b := make([]byte, length)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+
+		switch policy.Kind {
+		case KindHex:
+			return hex.EncodeToString(b), nil
+		case KindBase64:
+			return base64.StdEncoding.EncodeToString(b), nil
+		default:
+			return base58.Encode(b), nil
+		}
+	case KindUuid:
+		b := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+
+		// version 4, variant RFC 4122
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	case KindDiceware, KindBip39Words:
+		words := policy.Words
+		if words == 0 {
+			words = DefaultWords
+		}
+
+		if words < 0 {
+			return "", fmt.Errorf("invalid number of words %d", words)
+		}
+
+		separator := policy.Separator
+		if len(separator) == 0 {
+			separator = DefaultSeparator
+		}
+
+		list := Bip39Words()
+		out := make([]string, words)
+		for i := range out {
+			n, err := randInt(len(list))
+			if err != nil {
+				return "", err
+			}
+			out[i] = list[n]
+		}
+
+		return strings.Join(out, separator), nil
+	default:
+		return "", fmt.Errorf("invalid kind %s, valid kinds are %v", policy.Kind, Kinds())
+	}
+}
+
+// newPassword generates a password with at least one character
+// from each requested class
+func newPassword(policy *Policy) (string, error) {
+	length := policy.Length
+	if length == 0 {
+		length = DefaultPasswordLength
+	}
+
+	classNames := policy.Classes
+	if len(classNames) == 0 {
+		classNames = Classes()
+	}
+
+	if length < len(classNames) {
+		return "", fmt.Errorf("length %d is too short to include %d character classes", length, len(classNames))
+	}
+
+	sets := make([]string, 0, len(classNames))
+	alphabet := ""
+	for _, name := range classNames {
+		set, ok := classes[name]
+		if !ok {
+			return "", fmt.Errorf("invalid character class %s, valid classes are %v", name, Classes())
+		}
+
+		if policy.ExcludeAmbiguous {
+			set = strings.Map(
+				func(r rune) rune {
+					if strings.ContainsRune(ambiguous, r) {
+						return -1
+					}
+					return r
+				},
+				set,
+			)
+		}
+
+		sets = append(sets, set)
+		alphabet += set
+	}
+
+	out := make([]byte, length)
+	for i := range out {
+		set := alphabet
+		if i < len(sets) {
+			set = sets[i]
+		}
+
+		n, err := randInt(len(set))
+		if err != nil {
+			return "", err
+		}
+		out[i] = set[n]
+	}
+
+	// shuffle so that guaranteed class characters are not at the start
+	for i := len(out) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return string(out), nil
+}
+
+func randInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random number: %w", err)
+	}
+
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,11 @@
+package generate
+
+import (
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// Bip39Words returns BIP39 english word list in order of word index.
+// The list is shared and must not be modified.
+func Bip39Words() []string {
+	return wordlists.English
+}
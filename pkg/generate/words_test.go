@@ -0,0 +1,52 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBip39Words(t *testing.T) {
+	words := Bip39Words()
+	if len(words) != 2048 {
+		t.Fatalf("got %d words, want 2048", len(words))
+	}
+
+	seen := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if _, ok := seen[word]; ok {
+			t.Errorf("duplicate word %q", word)
+		}
+		seen[word] = struct{}{}
+	}
+
+	for i, want := range map[int]string{0: "abandon", 1: "ability", 2047: "zoo"} {
+		if words[i] != want {
+			t.Errorf("word %d = %q, want %q", i, words[i], want)
+		}
+	}
+}
+
+func TestDiceware(t *testing.T) {
+	for _, kind := range []string{KindDiceware, KindBip39Words} {
+		value, err := New(&Policy{Kind: kind, Words: 4, Separator: "."})
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+
+		words := strings.Split(value, ".")
+		if len(words) != 4 {
+			t.Fatalf("%s: got %d words in %q, want 4", kind, len(words), value)
+		}
+
+		list := make(map[string]struct{}, 2048)
+		for _, word := range Bip39Words() {
+			list[word] = struct{}{}
+		}
+
+		for _, word := range words {
+			if _, ok := list[word]; !ok {
+				t.Errorf("%s: word %q is not in BIP39 word list", kind, word)
+			}
+		}
+	}
+}
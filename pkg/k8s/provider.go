@@ -0,0 +1,179 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KindExternalSecret      = "external-secret"
+	KindSecretProviderClass = "secret-provider-class"
+)
+
+const (
+	SecretStoreKindNamespaced = "SecretStore"
+	SecretStoreKindCluster    = "ClusterSecretStore"
+)
+
+// SecretReference refers to a version of a secret in Google secret manager
+type SecretReference struct {
+	Key     string
+	Project string
+	Name    string
+	Version string
+}
+
+// ResourceName is the full Google secret manager resource name of the version
+func (r *SecretReference) ResourceName() string {
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", r.Project, r.Name, r.Version)
+}
+
+// SecretStoreRef refers to an External Secrets Operator secret store
+type SecretStoreRef struct {
+	Name string `json:"name" yaml:"name"`
+	Kind string `json:"kind" yaml:"kind"`
+}
+
+// ExternalSecretTarget is the Kubernetes secret created by the operator
+type ExternalSecretTarget struct {
+	Name           string `json:"name" yaml:"name"`
+	CreationPolicy string `json:"creationPolicy" yaml:"creationPolicy"`
+}
+
+// ExternalSecretRemoteRef refers to a secret version in the provider
+type ExternalSecretRemoteRef struct {
+	Key     string `json:"key" yaml:"key"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ExternalSecretData maps a Kubernetes secret key to a remote secret
+type ExternalSecretData struct {
+	SecretKey string                  `json:"secretKey" yaml:"secretKey"`
+	RemoteRef ExternalSecretRemoteRef `json:"remoteRef" yaml:"remoteRef"`
+}
+
+// ExternalSecretSpec is the spec of an ExternalSecret
+type ExternalSecretSpec struct {
+	RefreshInterval string               `json:"refreshInterval,omitempty" yaml:"refreshInterval,omitempty"`
+	SecretStoreRef  SecretStoreRef       `json:"secretStoreRef" yaml:"secretStoreRef"`
+	Target          ExternalSecretTarget `json:"target" yaml:"target"`
+	Data            []ExternalSecretData `json:"data" yaml:"data"`
+}
+
+// ExternalSecret is an External Secrets Operator manifest
+type ExternalSecret struct {
+	ApiVersion string             `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string             `json:"kind" yaml:"kind"`
+	Metadata   ObjectMeta         `json:"metadata" yaml:"metadata"`
+	Spec       ExternalSecretSpec `json:"spec" yaml:"spec"`
+}
+
+// SecretProviderClassSpec is the spec of a SecretProviderClass
+type SecretProviderClassSpec struct {
+	Provider   string            `json:"provider" yaml:"provider"`
+	Parameters map[string]string `json:"parameters" yaml:"parameters"`
+}
+
+// SecretProviderClass is a Secrets Store CSI driver manifest
+type SecretProviderClass struct {
+	ApiVersion string                  `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string                  `json:"kind" yaml:"kind"`
+	Metadata   ObjectMeta              `json:"metadata" yaml:"metadata"`
+	Spec       SecretProviderClassSpec `json:"spec" yaml:"spec"`
+}
+
+// csiSecret is an entry of the secrets parameter of the GCP CSI provider
+type csiSecret struct {
+	ResourceName string `yaml:"resourceName"`
+	FileName     string `yaml:"fileName"`
+}
+
+// NewExternalSecret builds an ExternalSecret that syncs referenced secrets
+// into a Kubernetes secret with the same name as the manifest
+func NewExternalSecret(
+	meta *ObjectMeta,
+	store SecretStoreRef,
+	refreshInterval string,
+	refs []SecretReference,
+) (*ExternalSecret, error) {
+	switch store.Kind {
+	case SecretStoreKindNamespaced, SecretStoreKindCluster:
+	default:
+		return nil, fmt.Errorf("invalid secret store kind %s, valid kinds are %v",
+			store.Kind,
+			[]string{SecretStoreKindNamespaced, SecretStoreKindCluster},
+		)
+	}
+
+	if len(store.Name) == 0 {
+		return nil, fmt.Errorf("secret store name cannot be empty")
+	}
+
+	data := make([]ExternalSecretData, 0, len(refs))
+	for _, ref := range refs {
+		data = append(
+			data,
+			ExternalSecretData{
+				SecretKey: ref.Key,
+				RemoteRef: ExternalSecretRemoteRef{
+					Key:     ref.Name,
+					Version: ref.Version,
+				},
+			},
+		)
+	}
+
+	return &ExternalSecret{
+		ApiVersion: "external-secrets.io/v1beta1",
+		Kind:       "ExternalSecret",
+		Metadata:   *meta,
+		Spec: ExternalSecretSpec{
+			RefreshInterval: refreshInterval,
+			SecretStoreRef:  store,
+			Target: ExternalSecretTarget{
+				Name:           meta.Name,
+				CreationPolicy: "Owner",
+			},
+			Data: data,
+		},
+	}, nil
+}
+
+// NewSecretProviderClass builds a SecretProviderClass for the GCP provider
+// of Secrets Store CSI driver mounting each referenced secret as a file
+// named after its key
+func NewSecretProviderClass(meta *ObjectMeta, refs []SecretReference) (*SecretProviderClass, error) {
+	secrets := make([]csiSecret, 0, len(refs))
+	for _, ref := range refs {
+		if strings.ContainsAny(ref.Key, "/\\") {
+			return nil, fmt.Errorf("invalid file name %s", ref.Key)
+		}
+
+		secrets = append(
+			secrets,
+			csiSecret{
+				ResourceName: ref.ResourceName(),
+				FileName:     ref.Key,
+			},
+		)
+	}
+
+	b, err := yaml.Marshal(secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize secrets parameter: %w", err)
+	}
+
+	return &SecretProviderClass{
+		ApiVersion: "secrets-store.csi.x-k8s.io/v1",
+		Kind:       "SecretProviderClass",
+		Metadata:   *meta,
+		Spec: SecretProviderClassSpec{
+			Provider: "gcp",
+			Parameters: map[string]string{
+				"secrets": string(b),
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,131 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	SecretTypeOpaque           = "opaque"
+	SecretTypeDockerConfigJson = "dockerconfigjson"
+	SecretTypeTls              = "tls"
+)
+
+const (
+	DockerConfigJsonKey = ".dockerconfigjson"
+	TlsCertKey          = "tls.crt"
+	TlsPrivateKeyKey    = "tls.key"
+)
+
+// secretTypes maps short secret type names to Kubernetes secret types
+var secretTypes = map[string]string{
+	SecretTypeOpaque:           "Opaque",
+	SecretTypeDockerConfigJson: "kubernetes.io/dockerconfigjson",
+	SecretTypeTls:              "kubernetes.io/tls",
+}
+
+// ObjectMeta is the subset of Kubernetes object metadata
+// used in generated manifests
+type ObjectMeta struct {
+	Name      string            `json:"name" yaml:"name"`
+	Namespace string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// Secret is a Kubernetes v1.Secret manifest
+type Secret struct {
+	ApiVersion string            `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string            `json:"kind" yaml:"kind"`
+	Metadata   ObjectMeta        `json:"metadata" yaml:"metadata"`
+	Type       string            `json:"type" yaml:"type"`
+	Data       map[string]string `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// NewObjectMeta validates and returns object metadata
+func NewObjectMeta(name, namespace string, labels map[string]string) (*ObjectMeta, error) {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid object name %s: %v", name, errs)
+	}
+
+	if len(namespace) > 0 {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid namespace %s: %v", namespace, errs)
+		}
+	}
+
+	for k, v := range labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %s: %v", k, errs)
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %s: %v", v, errs)
+		}
+	}
+
+	return &ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    labels,
+	}, nil
+}
+
+// NewSecret builds a Kubernetes secret of given type from data. Data values
+// are base64 encoded and the keys required by the secret type are validated.
+func NewSecret(meta *ObjectMeta, secretType string, data map[string][]byte) (*Secret, error) {
+	k8sType, ok := secretTypes[strings.ToLower(secretType)]
+	if !ok {
+		return nil, fmt.Errorf("invalid secret type %s, valid types are %v", secretType, SecretTypes())
+	}
+
+	for k := range data {
+		if errs := validation.IsConfigMapKey(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid secret key %s: %v", k, errs)
+		}
+	}
+
+	switch strings.ToLower(secretType) {
+	case SecretTypeDockerConfigJson:
+		value, ok := data[DockerConfigJsonKey]
+		if !ok {
+			return nil, fmt.Errorf("secret type %s requires key %s", secretType, DockerConfigJsonKey)
+		}
+		if !json.Valid(value) {
+			return nil, fmt.Errorf("value of key %s is not valid json", DockerConfigJsonKey)
+		}
+	case SecretTypeTls:
+		for _, k := range []string{TlsCertKey, TlsPrivateKeyKey} {
+			if _, ok := data[k]; !ok {
+				return nil, fmt.Errorf("secret type %s requires key %s", secretType, k)
+			}
+		}
+	}
+
+	encoded := make(map[string]string)
+	for k, v := range data {
+		encoded[k] = base64.StdEncoding.EncodeToString(v)
+	}
+
+	return &Secret{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   *meta,
+		Type:       k8sType,
+		Data:       encoded,
+	}, nil
+}
+
+// SecretTypes lists short names of supported secret types
+func SecretTypes() []string {
+	types := make([]string, 0, len(secretTypes))
+	for k := range secretTypes {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+
+	return types
+}
@@ -0,0 +1,195 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Manifest declares metadata of secrets managed by this app
+type Manifest struct {
+	Secrets []Secret `json:"secrets" yaml:"secrets"`
+}
+
+// Secret declares metadata of a secret. Payloads are only written when
+// a value source is provided.
+type Secret struct {
+	Name        string            `json:"name" yaml:"name"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Replication *Replication      `json:"replication,omitempty" yaml:"replication,omitempty"`
+	Ttl         string            `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	ExpireTime  string            `json:"expireTime,omitempty" yaml:"expireTime,omitempty"`
+	Rotation    *Rotation         `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+	Topics      []string          `json:"topics,omitempty" yaml:"topics,omitempty"`
+	Encrypted   bool              `json:"encrypted,omitempty" yaml:"encrypted,omitempty"`
+	Value       *ValueSource      `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// Replication declares replication policy. Automatic replication
+// is used when no locations are provided.
+type Replication struct {
+	Locations []string `json:"locations,omitempty" yaml:"locations,omitempty"`
+}
+
+// Rotation declares rotation schedule
+type Rotation struct {
+	Period           string `json:"period,omitempty" yaml:"period,omitempty"`
+	NextRotationTime string `json:"nextRotationTime,omitempty" yaml:"nextRotationTime,omitempty"`
+}
+
+// ValueSource declares where a secret value is read from
+type ValueSource struct {
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+	Env  string `json:"env,omitempty" yaml:"env,omitempty"`
+}
+
+// Load reads and validates a manifest file
+func Load(filename string) (*Manifest, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest file: %w", err)
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest file: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// Validate checks names, labels, durations and timestamps
+func (m *Manifest) Validate() error {
+	names := make(map[string]struct{})
+	for _, secret := range m.Secrets {
+		if err := namespace.Validate(secret.Name); err != nil {
+			return err
+		}
+
+		if _, ok := names[secret.Name]; ok {
+			return fmt.Errorf("duplicate secret %s", secret.Name)
+		}
+		names[secret.Name] = struct{}{}
+
+		for k, v := range secret.Labels {
+			if app.IsReservedLabel(k) {
+				return fmt.Errorf("secret %s: label %s is reserved for use by %s", secret.Name, k, app.Name)
+			}
+			if errs := validation.IsDNS1123Label(k); len(errs) > 0 {
+				return fmt.Errorf("secret %s: invalid label key %s: %v", secret.Name, k, errs)
+			}
+			if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+				return fmt.Errorf("secret %s: invalid label value %s: %v", secret.Name, v, errs)
+			}
+		}
+
+		if len(secret.Ttl) > 0 && len(secret.ExpireTime) > 0 {
+			return fmt.Errorf("secret %s: ttl and expireTime are mutually exclusive", secret.Name)
+		}
+
+		if _, err := secret.TtlDuration(); err != nil {
+			return fmt.Errorf("secret %s: %w", secret.Name, err)
+		}
+
+		if _, err := secret.ExpireTimestamp(); err != nil {
+			return fmt.Errorf("secret %s: %w", secret.Name, err)
+		}
+
+		if secret.Rotation != nil {
+			if _, err := secret.Rotation.PeriodDuration(); err != nil {
+				return fmt.Errorf("secret %s: %w", secret.Name, err)
+			}
+			if _, err := secret.Rotation.NextRotationTimestamp(); err != nil {
+				return fmt.Errorf("secret %s: %w", secret.Name, err)
+			}
+			if len(secret.Topics) == 0 {
+				return fmt.Errorf("secret %s: rotation requires at least one topic", secret.Name)
+			}
+		}
+
+		if secret.Value != nil {
+			if (len(secret.Value.File) > 0) == (len(secret.Value.Env) > 0) {
+				return fmt.Errorf("secret %s: value requires exactly one of file or env", secret.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// TtlDuration parses ttl, returning zero if not set
+func (s *Secret) TtlDuration() (time.Duration, error) {
+	return parseDuration("ttl", s.Ttl)
+}
+
+// ExpireTimestamp parses expire time, returning zero time if not set
+func (s *Secret) ExpireTimestamp() (time.Time, error) {
+	return parseTime("expireTime", s.ExpireTime)
+}
+
+// PeriodDuration parses rotation period, returning zero if not set
+func (r *Rotation) PeriodDuration() (time.Duration, error) {
+	return parseDuration("rotation period", r.Period)
+}
+
+// NextRotationTimestamp parses next rotation time, returning zero time if not set
+func (r *Rotation) NextRotationTimestamp() (time.Time, error) {
+	return parseTime("nextRotationTime", r.NextRotationTime)
+}
+
+// Read reads the value from its source
+func (v *ValueSource) Read() ([]byte, error) {
+	if len(v.File) > 0 {
+		b, err := os.ReadFile(v.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read value file: %w", err)
+		}
+		return b, nil
+	}
+
+	value, ok := os.LookupEnv(v.Env)
+	if !ok {
+		return nil, fmt.Errorf("env. var %s is not set", v.Env)
+	}
+
+	return []byte(value), nil
+}
+
+func parseDuration(field, input string) (time.Duration, error) {
+	if len(input) == 0 {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", field, err)
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s: must be positive", field)
+	}
+
+	return d, nil
+}
+
+func parseTime(field, input string) (time.Time, error) {
+	if len(input) == 0 {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, input)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s, need RFC3339 format: %w", field, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []Secret
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			secrets: []Secret{
+				{Name: "db-password", Labels: map[string]string{"team": "core"}, Ttl: "24h"},
+				{
+					Name:     "api-key",
+					Rotation: &Rotation{Period: "720h", NextRotationTime: "2030-01-01T00:00:00Z"},
+					Topics:   []string{"projects/p/topics/t"},
+					Value:    &ValueSource{Env: "API_KEY"},
+				},
+			},
+		},
+		{name: "namespaced name", secrets: []Secret{{Name: "team/app/db-password"}}},
+		{name: "invalid namespaced name", secrets: []Secret{{Name: "team//db-password"}}, wantErr: true},
+		{name: "invalid name", secrets: []Secret{{Name: "DB_PASSWORD"}}, wantErr: true},
+		{name: "duplicate name", secrets: []Secret{{Name: "a"}, {Name: "a"}}, wantErr: true},
+		{name: "invalid label key", secrets: []Secret{{Name: "a", Labels: map[string]string{"Team": "x"}}}, wantErr: true},
+		{name: "invalid label value", secrets: []Secret{{Name: "a", Labels: map[string]string{"team": "a b"}}}, wantErr: true},
+		{name: "reserved label managed-by", secrets: []Secret{{Name: "a", Labels: map[string]string{"managed-by": "foo"}}}, wantErr: true},
+		{name: "reserved label encrypted", secrets: []Secret{{Name: "a", Labels: map[string]string{"encrypted": "true"}}}, wantErr: true},
+		{name: "reserved label namespace", secrets: []Secret{{Name: "a", Labels: map[string]string{"namespace": "team"}}}, wantErr: true},
+		{name: "ttl and expire time", secrets: []Secret{{Name: "a", Ttl: "1h", ExpireTime: "2030-01-01T00:00:00Z"}}, wantErr: true},
+		{name: "invalid ttl", secrets: []Secret{{Name: "a", Ttl: "1 day"}}, wantErr: true},
+		{name: "negative ttl", secrets: []Secret{{Name: "a", Ttl: "-1h"}}, wantErr: true},
+		{name: "invalid expire time", secrets: []Secret{{Name: "a", ExpireTime: "2030-01-01"}}, wantErr: true},
+		{name: "rotation without topics", secrets: []Secret{{Name: "a", Rotation: &Rotation{Period: "1h"}}}, wantErr: true},
+		{name: "value without source", secrets: []Secret{{Name: "a", Value: &ValueSource{}}}, wantErr: true},
+		{name: "value with both sources", secrets: []Secret{{Name: "a", Value: &ValueSource{File: "f", Env: "E"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m := &Manifest{Secrets: tt.secrets}
+		if err := m.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(filename, []byte(`secrets:
+  - name: db-password
+    encrypted: true
+    ttl: 24h
+    value:
+      file: db-password.txt
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m.Secrets) != 1 {
+		t.Fatalf("expected one secret, got %d", len(m.Secrets))
+	}
+
+	s := m.Secrets[0]
+	if s.Name != "db-password" || !s.Encrypted || s.Value == nil || s.Value.File != "db-password.txt" {
+		t.Errorf("unexpected secret %+v", s)
+	}
+
+	if d, err := s.TtlDuration(); err != nil || d.Hours() != 24 {
+		t.Errorf("TtlDuration() = %v, %v, want 24h", d, err)
+	}
+}
+
+func TestValueSourceRead(t *testing.T) {
+	t.Setenv("MANIFEST_TEST_VALUE", "from-env")
+
+	if b, err := (&ValueSource{Env: "MANIFEST_TEST_VALUE"}).Read(); err != nil || string(b) != "from-env" {
+		t.Errorf("Read() = %q, %v, want from-env", b, err)
+	}
+
+	if _, err := (&ValueSource{Env: "MANIFEST_TEST_UNSET"}).Read(); err == nil {
+		t.Errorf("Read() of unset env. var did not fail")
+	}
+}
@@ -0,0 +1,144 @@
+package namespace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// Separator separates namespaces in path-style names
+	Separator = "/"
+
+	// idSeparator replaces Separator in secret IDs. It is valid in
+	// secret IDs and label values but never appears in a DNS1123 label,
+	// so IDs of namespaced names can not collide with flat names.
+	idSeparator = "_"
+)
+
+// Validate checks that name is a path of DNS1123 labels such as
+// team/app/db-password. Names without a namespace are single labels.
+func Validate(name string) error {
+	if len(name) == 0 {
+		return fmt.Errorf("please provide name of the secret")
+	}
+
+	for _, segment := range strings.Split(name, Separator) {
+		if errs := validation.IsDNS1123Label(segment); len(errs) > 0 {
+			return fmt.Errorf("invalid name %s, each path segment needs DNS1123Label format: %v", name, errs)
+		}
+	}
+
+	if ns, _ := Split(name); len(ns) > 0 {
+		if errs := validation.IsValidLabelValue(Label(ns)); len(errs) > 0 {
+			return fmt.Errorf("invalid name %s, namespace %s is too long: %v", name, ns, errs)
+		}
+	}
+
+	return nil
+}
+
+// ToID maps a path-style name to a valid secret ID
+func ToID(name string) (string, error) {
+	if err := Validate(name); err != nil {
+		return "", err
+	}
+
+	return strings.ReplaceAll(name, Separator, idSeparator), nil
+}
+
+// FromID maps a secret ID back to its path-style name
+func FromID(id string) string {
+	return strings.ReplaceAll(id, idSeparator, Separator)
+}
+
+// Split splits name into its namespace and base name. Namespace is
+// empty for flat names.
+func Split(name string) (string, string) {
+	i := strings.LastIndex(name, Separator)
+	if i < 0 {
+		return "", name
+	}
+
+	return name[:i], name[i+1:]
+}
+
+// Label encodes a namespace as a label value
+func Label(ns string) string {
+	return strings.ReplaceAll(ns, Separator, idSeparator)
+}
+
+// Defaults are settings applied to secrets created in a namespace
+// unless overridden by flags
+type Defaults struct {
+	Encrypt bool              `json:"encrypt,omitempty" yaml:"encrypt,omitempty" mapstructure:"encrypt"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty" mapstructure:"labels"`
+}
+
+// Lookup returns defaults of the most specific namespace of name,
+// i.e., defaults of team/app take precedence over those of team
+func Lookup(defaults map[string]Defaults, name string) (Defaults, bool) {
+	for ns, _ := Split(name); len(ns) > 0; ns, _ = Split(ns) {
+		if d, ok := defaults[ns]; ok {
+			return d, true
+		}
+	}
+
+	return Defaults{}, false
+}
+
+type node struct {
+	children map[string]*node
+}
+
+// Tree writes names as a tree of namespaces
+func Tree(w io.Writer, names []string) error {
+	root := &node{children: make(map[string]*node)}
+	for _, name := range names {
+		n := root
+		for _, segment := range strings.Split(name, Separator) {
+			child, ok := n.children[segment]
+			if !ok {
+				child = &node{children: make(map[string]*node)}
+				n.children[segment] = child
+			}
+			n = child
+		}
+	}
+
+	return root.write(w, "")
+}
+
+func (n *node) write(w io.Writer, indent string) error {
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		branch, next := "├── ", "│   "
+		if i == len(keys)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		child := n.children[k]
+		label := k
+		if len(child.children) > 0 {
+			label += Separator
+		}
+
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", indent, branch, label); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+
+		if err := child.write(w, indent+next); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
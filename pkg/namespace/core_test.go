@@ -0,0 +1,106 @@
+package namespace
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToID(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "db-password", want: "db-password"},
+		{name: "team/app/db-password", want: "team_app_db-password"},
+		{name: "", wantErr: true},
+		{name: "team//db-password", wantErr: true},
+		{name: "/db-password", wantErr: true},
+		{name: "team/", wantErr: true},
+		{name: "Team/db-password", wantErr: true},
+		{name: "team_app/db-password", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ToID(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ToID(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ToID(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+
+		if !tt.wantErr && FromID(got) != tt.name {
+			t.Errorf("FromID(%q) = %q, want %q", got, FromID(got), tt.name)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantNs string
+		want   string
+	}{
+		{name: "db-password", wantNs: "", want: "db-password"},
+		{name: "team/db-password", wantNs: "team", want: "db-password"},
+		{name: "team/app/db-password", wantNs: "team/app", want: "db-password"},
+	}
+
+	for _, tt := range tests {
+		ns, base := Split(tt.name)
+		if ns != tt.wantNs || base != tt.want {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", tt.name, ns, base, tt.wantNs, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	defaults := map[string]Defaults{
+		"team":     {Labels: map[string]string{"owner": "team"}},
+		"team/app": {Encrypt: true},
+	}
+
+	tests := []struct {
+		name   string
+		want   Defaults
+		wantOk bool
+	}{
+		{name: "db-password", wantOk: false},
+		{name: "team/db-password", want: defaults["team"], wantOk: true},
+		{name: "team/app/db-password", want: defaults["team/app"], wantOk: true},
+		{name: "team/app/sub/db-password", want: defaults["team/app"], wantOk: true},
+		{name: "other/db-password", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Lookup(defaults, tt.name)
+		if ok != tt.wantOk {
+			t.Errorf("Lookup(%q) ok = %v, want %v", tt.name, ok, tt.wantOk)
+			continue
+		}
+
+		if got.Encrypt != tt.want.Encrypt || len(got.Labels) != len(tt.want.Labels) {
+			t.Errorf("Lookup(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	var b bytes.Buffer
+	if err := Tree(&b, []string{"team/app/db-password", "team/api-key", "root-key"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `├── root-key
+└── team/
+    ├── api-key
+    └── app/
+        └── db-password
+`
+	if b.String() != want {
+		t.Errorf("Tree() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,247 @@
+package picker
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const maxVisible = 10
+
+// ErrCancelled is returned when user cancels picking
+var ErrCancelled = errors.New("cancelled")
+
+// Item is an entry that can be picked. Detail is shown next to
+// the name but is not searched.
+type Item struct {
+	Name   string
+	Detail string
+}
+
+// Score scores a fuzzy match of pattern against candidate. Pattern
+// characters need to appear in candidate in order. Consecutive matches
+// and matches at start of words score higher. Matching is case-insensitive.
+func Score(pattern, candidate string) (int, bool) {
+	if len(pattern) == 0 {
+		return 0, true
+	}
+
+	p := []rune(strings.ToLower(pattern))
+	c := []rune(strings.ToLower(candidate))
+
+	score := 0
+	prev := -2
+	j := 0
+	for i := 0; i < len(c) && j < len(p); i++ {
+		if c[i] != p[j] {
+			continue
+		}
+
+		score++
+		if i == prev+1 {
+			score += 5
+		}
+		if i == 0 || !unicode.IsLetter(c[i-1]) && !unicode.IsDigit(c[i-1]) {
+			score += 3
+		}
+
+		prev = i
+		j++
+	}
+
+	if j < len(p) {
+		return 0, false
+	}
+
+	// prefer shorter candidates for equal matches
+	return score*100 - len(c), true
+}
+
+// Filter returns items matching pattern, best matches first
+func Filter(pattern string, items []Item) []Item {
+	type scored struct {
+		item  Item
+		score int
+	}
+
+	matches := make([]scored, 0, len(items))
+	for _, item := range items {
+		if score, ok := Score(pattern, item.Name); ok {
+			matches = append(matches, scored{item: item, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	out := make([]Item, len(matches))
+	for i := range matches {
+		out[i] = matches[i].item
+	}
+
+	return out
+}
+
+// IsTerminal checks if input is a terminal on which picker can run
+func IsTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Pick shows a fuzzy searchable list of items on terminal in and w
+// and returns name of the picked item. Typing filters the list, arrow
+// keys or Ctrl-P and Ctrl-N move selection, Enter picks and Esc or
+// Ctrl-C cancels.
+func Pick(in *os.File, w io.Writer, prompt string, items []Item) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("nothing to pick from")
+	}
+
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to set terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	p := &picker{
+		w:      w,
+		prompt: prompt,
+		items:  items,
+		shown:  items,
+	}
+	defer p.clear()
+
+	r := bufio.NewReader(in)
+	for {
+		if err := p.render(); err != nil {
+			return "", err
+		}
+
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return "", fmt.Errorf("failed to read from terminal: %w", err)
+		}
+
+		switch c {
+		case '\r', '\n':
+			if len(p.shown) == 0 {
+				continue
+			}
+			return p.shown[p.selected].Name, nil
+		case 3: // Ctrl-C
+			return "", ErrCancelled
+		case 27: // Esc or start of an escape sequence
+			if r.Buffered() == 0 {
+				return "", ErrCancelled
+			}
+			if next, _, _ := r.ReadRune(); next != '[' {
+				continue
+			}
+			switch code, _, _ := r.ReadRune(); code {
+			case 'A':
+				p.move(-1)
+			case 'B':
+				p.move(1)
+			}
+		case 16: // Ctrl-P
+			p.move(-1)
+		case 14: // Ctrl-N
+			p.move(1)
+		case 127, 8: // Backspace
+			if len(p.query) > 0 {
+				_, size := utf8.DecodeLastRuneInString(p.query)
+				p.setQuery(p.query[:len(p.query)-size])
+			}
+		case 21: // Ctrl-U
+			p.setQuery("")
+		default:
+			if unicode.IsPrint(c) {
+				p.setQuery(p.query + string(c))
+			}
+		}
+	}
+}
+
+type picker struct {
+	w        io.Writer
+	prompt   string
+	items    []Item
+	shown    []Item
+	query    string
+	selected int
+	lines    int
+}
+
+func (p *picker) setQuery(query string) {
+	p.query = query
+	p.shown = Filter(query, p.items)
+	p.selected = 0
+}
+
+func (p *picker) move(delta int) {
+	n := len(p.shown)
+	if n > maxVisible {
+		n = maxVisible
+	}
+	if n == 0 {
+		return
+	}
+
+	p.selected = (p.selected + delta + n) % n
+}
+
+// clear erases lines rendered previously leaving cursor at the prompt line
+func (p *picker) clear() {
+	_, _ = fmt.Fprint(p.w, "\r\x1b[2K")
+	for i := 0; i < p.lines; i++ {
+		_, _ = fmt.Fprint(p.w, "\x1b[1B\r\x1b[2K")
+	}
+	if p.lines > 0 {
+		_, _ = fmt.Fprintf(p.w, "\x1b[%dA", p.lines)
+	}
+}
+
+func (p *picker) render() error {
+	p.clear()
+
+	var sb strings.Builder
+	visible := p.shown
+	if len(visible) > maxVisible {
+		visible = visible[:maxVisible]
+	}
+
+	for i, item := range visible {
+		line := item.Name
+		if len(item.Detail) > 0 {
+			line = fmt.Sprintf("%s  \x1b[2m%s\x1b[0m", item.Name, item.Detail)
+		}
+
+		if i == p.selected {
+			sb.WriteString(fmt.Sprintf("\r\n\x1b[7m> %s\x1b[0m", line))
+		} else {
+			sb.WriteString(fmt.Sprintf("\r\n  %s", line))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\r\n  \x1b[2m%d/%d\x1b[0m", len(p.shown), len(p.items)))
+	p.lines = len(visible) + 1
+
+	// move back up to prompt line and place cursor after query
+	sb.WriteString(fmt.Sprintf("\x1b[%dA\r%s: %s", p.lines, p.prompt, p.query))
+
+	if _, err := io.WriteString(p.w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+
+	return nil
+}
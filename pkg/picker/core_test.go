@@ -0,0 +1,79 @@
+package picker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		candidate string
+		wantOk    bool
+	}{
+		{pattern: "", candidate: "db-password", wantOk: true},
+		{pattern: "dbp", candidate: "db-password", wantOk: true},
+		{pattern: "DBP", candidate: "db-password", wantOk: true},
+		{pattern: "pdb", candidate: "db-password", wantOk: false},
+		{pattern: "dbx", candidate: "db-password", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := Score(tt.pattern, tt.candidate); ok != tt.wantOk {
+			t.Errorf("Score(%q, %q) ok = %v, want %v", tt.pattern, tt.candidate, ok, tt.wantOk)
+		}
+	}
+}
+
+func TestScoreRanking(t *testing.T) {
+	tests := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		// consecutive matches win over scattered ones
+		{pattern: "pass", better: "db-password", worse: "p-a-s-s"},
+		// matches at start of words win over matches within words
+		{pattern: "ak", better: "api-key", worse: "marker"},
+		// shorter candidates win for equal matches
+		{pattern: "db", better: "db", worse: "db-password"},
+	}
+
+	for _, tt := range tests {
+		better, _ := Score(tt.pattern, tt.better)
+		worse, _ := Score(tt.pattern, tt.worse)
+		if better <= worse {
+			t.Errorf("Score(%q) of %q = %d, not above %q = %d", tt.pattern, tt.better, better, tt.worse, worse)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{
+		{Name: "api-key"},
+		{Name: "db-password", Detail: "encrypted"},
+		{Name: "db-user"},
+		{Name: "token"},
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "", want: []string{"api-key", "db-password", "db-user", "token"}},
+		{pattern: "dbu", want: []string{"db-user"}},
+		{pattern: "db", want: []string{"db-user", "db-password"}},
+		{pattern: "xyz", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		got := make([]string, 0, len(items))
+		for _, item := range Filter(tt.pattern, items) {
+			got = append(got, item.Name)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package prompter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter writes prompts and reads user input in response
+type Prompter interface {
+	// Print writes an informational message
+	Print(message string) error
+	// Line prompts with message and reads a line of input
+	Line(message string) (string, error)
+	// Password prompts with message and reads a line of input without echo
+	Password(message string) ([]byte, error)
+	// All prompts with message and reads input until EOF
+	All(message string) ([]byte, error)
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying prompter p. Commands use it
+// instead of their own input and output, which allows scripting them.
+func WithContext(ctx context.Context, p Prompter) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns prompter carried by the context, if any
+func FromContext(ctx context.Context) (Prompter, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	p, ok := ctx.Value(contextKey{}).(Prompter)
+	return p, ok
+}
+
+// Interactive checks if input is connected to a file or a terminal
+// rather than a pipe. Prompts can be hidden when it is not, assuming
+// input is being fed via pipe.
+func Interactive(in io.Reader) (bool, error) {
+	f, ok := in.(*os.File)
+	if !ok {
+		return false, nil
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to stat input: %w", err)
+	}
+
+	return fi.Mode()&os.ModeNamedPipe == 0, nil
+}
+
+// terminal prompts on w and reads from in, disabling echo when
+// reading passwords if in is a terminal
+type terminal struct {
+	in *bufio.Reader
+	fd int
+	w  io.Writer
+}
+
+// New creates a prompter reading input from in and writing prompts to w,
+// which is usually stderr so that prompts do not mix with command output.
+// Prompts are not written when show is false.
+func New(in io.Reader, w io.Writer, show bool) Prompter {
+	fd := -1
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fd = int(f.Fd())
+	}
+
+	if !show {
+		w = io.Discard
+	}
+
+	return &terminal{
+		in: bufio.NewReader(in),
+		fd: fd,
+		w:  w,
+	}
+}
+
+func (t *terminal) Print(message string) error {
+	if _, err := fmt.Fprintln(t.w, message); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func (t *terminal) prompt(message string) error {
+	if _, err := fmt.Fprintf(t.w, "%s: ", message); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func (t *terminal) readLine() (string, error) {
+	line, err := t.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+		return "", fmt.Errorf("failed to read from input: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (t *terminal) Line(message string) (string, error) {
+	if err := t.prompt(message); err != nil {
+		return "", err
+	}
+
+	return t.readLine()
+}
+
+func (t *terminal) Password(message string) ([]byte, error) {
+	if err := t.prompt(message); err != nil {
+		return nil, err
+	}
+
+	if t.fd < 0 {
+		line, err := t.readLine()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(line), nil
+	}
+
+	password, err := term.ReadPassword(t.fd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password from input: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(t.w); err != nil {
+		return nil, fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return password, nil
+}
+
+func (t *terminal) All(message string) ([]byte, error) {
+	if err := t.prompt(message); err != nil {
+		return nil, err
+	}
+
+	b, err := io.ReadAll(t.in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from input: %w", err)
+	}
+
+	return b, nil
+}
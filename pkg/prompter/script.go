@@ -0,0 +1,59 @@
+package prompter
+
+import (
+	"fmt"
+)
+
+// Script is a prompter that answers prompts with predefined inputs
+// in order and records prompts it was asked. It is meant for driving
+// commands non-interactively, for instance in tests.
+type Script struct {
+	Inputs   []string
+	Prompts  []string
+	Messages []string
+}
+
+// NewScript creates a prompter answering prompts with inputs
+func NewScript(inputs ...string) *Script {
+	return &Script{Inputs: inputs}
+}
+
+func (s *Script) next(message string) (string, error) {
+	s.Prompts = append(s.Prompts, message)
+
+	if len(s.Inputs) == 0 {
+		return "", fmt.Errorf("no scripted input left for prompt %q", message)
+	}
+
+	input := s.Inputs[0]
+	s.Inputs = s.Inputs[1:]
+
+	return input, nil
+}
+
+func (s *Script) Print(message string) error {
+	s.Messages = append(s.Messages, message)
+	return nil
+}
+
+func (s *Script) Line(message string) (string, error) {
+	return s.next(message)
+}
+
+func (s *Script) Password(message string) ([]byte, error) {
+	input, err := s.next(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(input), nil
+}
+
+func (s *Script) All(message string) ([]byte, error) {
+	input, err := s.next(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(input), nil
+}
@@ -0,0 +1,249 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/agent"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/kubetrail/mksecret/pkg/prompter"
+	"github.com/mr-tron/base58"
+	"google.golang.org/api/iterator"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// secretValue is a decrypted payload of a secret version
+type secretValue struct {
+	Name      string
+	Version   string
+	Payload   []byte
+	Encrypted bool
+	Labels    map[string]string
+}
+
+// secretRef maps a key, such as an env. var, to a secret name and version
+type secretRef struct {
+	key     string
+	name    string
+	version string
+}
+
+// parseSecretRef parses input of the form KEY=name[@version]
+func parseSecretRef(input string) (*secretRef, error) {
+	key, ref, ok := strings.Cut(input, "=")
+	if !ok || len(key) == 0 || len(ref) == 0 {
+		return nil, fmt.Errorf("invalid mapping %q, expected KEY=name[@version]", input)
+	}
+
+	name, version, ok := strings.Cut(ref, "@")
+	if !ok {
+		version = "latest"
+	}
+
+	if len(version) == 0 {
+		return nil, fmt.Errorf("invalid mapping %q, version cannot be empty", input)
+	}
+
+	return &secretRef{
+		key:     key,
+		name:    name,
+		version: version,
+	}, nil
+}
+
+// secretReader fetches secret versions managed by this app and
+// decrypts them when required. Passphrase is prompted for when not
+// provided and is then reused for subsequent reads.
+type secretReader struct {
+	client     *secretmanager.Client
+	project    string
+	passphrase string
+	prompt     prompter.Prompter
+
+	// prompted is set when passphrase was prompted for rather than
+	// provided
+	prompted bool
+}
+
+// read fetches a version of named secret. Version can be a version
+// number or an alias such as latest.
+func (r *secretReader) read(ctx context.Context, name, version string) (*secretValue, error) {
+	secret, err := getManagedSecret(ctx, r.client, r.project, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(version) == 0 {
+		version = "latest"
+	}
+
+	labels := secret.GetLabels()
+	encrypted := false
+	if value, ok := labels[app.KeyEncrypted]; ok && value == app.ValueTrue {
+		encrypted = true
+	}
+
+	// Build the request.
+	accessRequest := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("%s/versions/%s", secret.GetName(), version),
+	}
+
+	// Call the API.
+	result, err := r.client.AccessSecretVersion(ctx, accessRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access secret version: %w", err)
+	}
+
+	payload := result.Payload.GetData()
+
+	if encrypted {
+		ciphertext, err := base58.Decode(string(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to base58 decode stored value: %w", err)
+		}
+
+		payload, err = r.decrypt(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &secretValue{
+		Name:      name,
+		Version:   path.Base(result.GetName()),
+		Payload:   payload,
+		Encrypted: encrypted,
+		Labels:    labels,
+	}, nil
+}
+
+// decrypt decrypts ciphertext using passphrase. When passphrase is not
+// provided, agent is consulted, if available, before prompting for it.
+// Secrets may be protected by different passphrases, so a prompted
+// passphrase that fails to decrypt is prompted for again.
+func (r *secretReader) decrypt(ciphertext []byte) ([]byte, error) {
+	if len(r.passphrase) > 0 {
+		plaintext, err := decryptWithPassphrase(ciphertext, r.passphrase)
+		if err == nil || !r.prompted {
+			return plaintext, err
+		}
+	}
+
+	if client, ok := agent.NewClientFromEnv(); ok {
+		if plaintext, err := client.Decrypt(ciphertext); err == nil {
+			return plaintext, nil
+		}
+	}
+
+	passphrase, err := promptPassphrase(r.prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptWithPassphrase(ciphertext, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	r.passphrase = passphrase
+	r.prompted = true
+
+	return plaintext, nil
+}
+
+// decryptWithPassphrase decrypts ciphertext using AES key derived
+// from passphrase
+func decryptWithPassphrase(ciphertext []byte, passphrase string) ([]byte, error) {
+	key, err := crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate new AES key: %w", err)
+	}
+
+	plaintext, err := crypto.DecryptWithAesKey(ciphertext, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// getManagedSecret fetches metadata of a named secret and ensures
+// that it is managed by this app. Payload is not accessed.
+func getManagedSecret(
+	ctx context.Context,
+	client *secretmanager.Client,
+	project, name string,
+) (*secretmanagerpb.Secret, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("please provide name of the secret")
+	}
+
+	id, err := namespace.ToID(name)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.GetSecret(
+		ctx,
+		&secretmanagerpb.GetSecretRequest{
+			Name: fmt.Sprintf("projects/%s/secrets/%s", project, id),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+
+	labels := secret.GetLabels()
+	if value, ok := labels[app.KeyManagedBy]; !ok || value != app.Name {
+		return nil, fmt.Errorf("secret %s is not being managed by this app", name)
+	}
+
+	return secret, nil
+}
+
+// listSecrets lists all secrets managed by this app in a project
+// whose labels match the selector. An empty selector matches all.
+func listSecrets(
+	ctx context.Context,
+	client *secretmanager.Client,
+	project, selector string,
+) ([]*secretmanagerpb.Secret, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	// Build the request.
+	listRequest := &secretmanagerpb.ListSecretsRequest{
+		Parent: fmt.Sprintf("projects/%s", project),
+		Filter: fmt.Sprintf("labels.%s=%s", app.KeyManagedBy, app.Name),
+	}
+
+	// Call the API.
+	it := client.ListSecrets(ctx, listRequest)
+
+	secrets := make([]*secretmanagerpb.Secret, 0, 128)
+	for {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+
+		if !sel.Matches(labels.Set(secret.GetLabels())) {
+			continue
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	return secrets, nil
+}
@@ -0,0 +1,200 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/agent"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// agentClient returns client of agent pointed to by env. var
+func agentClient() (*agent.Client, error) {
+	client, ok := agent.NewClientFromEnv()
+	if !ok {
+		return nil, fmt.Errorf("agent is not available, %s is not set", agent.EnvAuthSock)
+	}
+
+	return client, nil
+}
+
+// agentEncrypt encrypts plaintext via agent using the key that decrypts
+// stored payload of a version, so that plaintext is protected the same
+// way as the version
+func agentEncrypt(
+	ctx context.Context,
+	client *secretmanager.Client,
+	keyAgent *agent.Client,
+	versionName string,
+	plaintext []byte,
+) ([]byte, error) {
+	payload, err := accessVersion(ctx, client, versionName)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := base58.Decode(string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to base58 decode stored value: %w", err)
+	}
+
+	ciphertext, err = keyAgent.Encrypt(plaintext, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with agent: %w", err)
+	}
+
+	return ciphertext, nil
+}
+
+func Agent(cmd *cobra.Command, args []string) error {
+	_ = viper.BindPFlag(flags.Socket, cmd.Flag(flags.Socket))
+	_ = viper.BindPFlag(flags.Timeout, cmd.Flag(flags.Timeout))
+
+	socket := viper.GetString(flags.Socket)
+	timeout := viper.GetDuration(flags.Timeout)
+
+	if len(socket) == 0 {
+		dir, err := os.MkdirTemp("", "mksecret-")
+		if err != nil {
+			return fmt.Errorf("failed to create socket dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		socket = filepath.Join(dir, "agent.sock")
+	}
+
+	// socket is created accessible to owner only, since setting its
+	// permissions after listening leaves a window open to others
+	mask := syscall.Umask(0077)
+	listener, err := net.Listen("unix", socket)
+	syscall.Umask(mask)
+	if err != nil {
+		return fmt.Errorf("failed to listen on socket: %w", err)
+	}
+	defer os.Remove(socket)
+
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s=%s; export %s;\n",
+		agent.EnvAuthSock, socket, agent.EnvAuthSock); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	return agent.NewServer(timeout).Serve(ctx, listener)
+}
+
+func AgentAdd(cmd *cobra.Command, args []string) error {
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := agentClient()
+	if err != nil {
+		return err
+	}
+
+	if len(passphrase) == 0 {
+		prompt, err := newPrompter(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		passphrase, err = promptPassphrase(prompt)
+		if err != nil {
+			return err
+		}
+	}
+
+	key, err := crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+	if err != nil {
+		return fmt.Errorf("failed to generate new AES key: %w", err)
+	}
+
+	if err := client.Add(key); err != nil {
+		return fmt.Errorf("failed to add key to agent: %w", err)
+	}
+
+	return nil
+}
+
+func AgentLock(cmd *cobra.Command, args []string) error {
+	client, err := agentClient()
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	password, err := prompt.Password("Enter lock password")
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	confirm, err := prompt.Password("Enter lock password again")
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if !bytes.Equal(password, confirm) {
+		return fmt.Errorf("passwords do not match")
+	}
+
+	if err := client.Lock(password); err != nil {
+		return fmt.Errorf("failed to lock agent: %w", err)
+	}
+
+	return nil
+}
+
+func AgentUnlock(cmd *cobra.Command, args []string) error {
+	client, err := agentClient()
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, false)
+	if err != nil {
+		return err
+	}
+
+	password, err := prompt.Password("Enter lock password")
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	if err := client.Unlock(password); err != nil {
+		return fmt.Errorf("failed to unlock agent: %w", err)
+	}
+
+	return nil
+}
+
+func AgentForget(cmd *cobra.Command, args []string) error {
+	client, err := agentClient()
+	if err != nil {
+		return err
+	}
+
+	if err := client.Forget(); err != nil {
+		return fmt.Errorf("failed to forget keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,532 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/bundle"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ConflictSkip       = "skip"
+	ConflictOverwrite  = "overwrite"
+	ConflictNewVersion = "new-version"
+)
+
+// restoreResult reports how a secret was restored
+type restoreResult struct {
+	Name     string `json:"name" yaml:"name"`
+	Action   string `json:"action" yaml:"action"`
+	Versions int    `json:"versions" yaml:"versions"`
+}
+
+// bundleSecretFromSecret converts secret metadata to bundle format
+func bundleSecretFromSecret(secret *secretmanagerpb.Secret) bundle.Secret {
+	s := bundle.Secret{
+		Name:      logicalName(secret),
+		Labels:    secret.GetLabels(),
+		Locations: replicationLocations(secret.GetReplication()),
+		Topics:    topicNames(secret.GetTopics()),
+	}
+
+	if expireTime := secret.GetExpireTime(); expireTime != nil {
+		t := expireTime.AsTime()
+		s.ExpireTime = &t
+	}
+
+	if rotation := secret.GetRotation(); rotation != nil {
+		s.RotationPeriod = rotation.GetRotationPeriod().AsDuration()
+		if next := rotation.GetNextRotationTime(); next != nil {
+			t := next.AsTime()
+			s.NextRotationTime = &t
+		}
+	}
+
+	return s
+}
+
+// secretFromBundleSecret converts bundle format to secret metadata.
+// Expiration in the past is dropped and rotation is moved to the
+// future since they cannot be set in the past. Topics, and hence
+// rotation, are dropped unless copyTopics is set.
+func secretFromBundleSecret(s *bundle.Secret, copyTopics bool) *secretmanagerpb.Secret {
+	secret := &secretmanagerpb.Secret{
+		Labels: s.Labels,
+	}
+
+	if len(s.Locations) > 0 {
+		replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(s.Locations))
+		for _, location := range s.Locations {
+			replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+		}
+		secret.Replication = &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_UserManaged_{
+				UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+			},
+		}
+	}
+
+	now := time.Now()
+
+	if s.ExpireTime != nil && s.ExpireTime.After(now) {
+		secret.Expiration = &secretmanagerpb.Secret_ExpireTime{ExpireTime: timestamppb.New(*s.ExpireTime)}
+	}
+
+	if copyTopics && len(s.Topics) > 0 {
+		for _, topic := range s.Topics {
+			secret.Topics = append(secret.Topics, &secretmanagerpb.Topic{Name: topic})
+		}
+
+		if next, ok := nextRotationTime(s.NextRotationTime, s.RotationPeriod, now); ok {
+			secret.Rotation = &secretmanagerpb.Rotation{
+				NextRotationTime: timestamppb.New(next),
+			}
+			if s.RotationPeriod > 0 {
+				secret.Rotation.RotationPeriod = durationpb.New(s.RotationPeriod)
+			}
+		}
+	}
+
+	return secret
+}
+
+// overwriteSecret restores metadata of a bundle secret into an existing
+// secret and returns versions of the existing secret that are to be
+// destroyed once bundle versions have been added. Existing secret is
+// never deleted, so a failed restore does not lose it.
+func overwriteSecret(
+	ctx context.Context,
+	client *secretmanager.Client,
+	existing *secretmanagerpb.Secret,
+	s *bundle.Secret,
+	copyTopics bool,
+) (*secretmanagerpb.Secret, []*secretmanagerpb.SecretVersion, error) {
+	locations := append([]string(nil), s.Locations...)
+	sort.Strings(locations)
+	if strings.Join(locations, ",") != strings.Join(replicationLocations(existing.GetReplication()), ",") {
+		return nil, nil, fmt.Errorf("secret %s replication differs from bundle and this property is immutable", s.Name)
+	}
+
+	versions, err := listVersions(ctx, client, existing.GetName(), "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stale := make([]*secretmanagerpb.SecretVersion, 0, len(versions))
+	for _, version := range versions {
+		if version.GetState() != secretmanagerpb.SecretVersion_DESTROYED {
+			stale = append(stale, version)
+		}
+	}
+
+	want := secretFromBundleSecret(s, copyTopics)
+	want.Name = existing.GetName()
+	want.Labels = namespaceLabels(want.GetLabels(), s.Name)
+
+	secret, err := client.UpdateSecret(
+		ctx,
+		&secretmanagerpb.UpdateSecretRequest{
+			Secret: want,
+			UpdateMask: &fieldmaskpb.FieldMask{
+				Paths: []string{"labels", "expire_time", "topics", "rotation"},
+			},
+		},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update secret %s: %w", s.Name, err)
+	}
+
+	return secret, stale, nil
+}
+
+func Backup(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Out, cmd.Flag(flags.Out))
+	_ = viper.BindPFlag(flags.Selector, cmd.Flag(flags.Selector))
+	_ = viper.BindPFlag(flags.Recipient, cmd.Flag(flags.Recipient))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	out := viper.GetString(flags.Out)
+	selector := viper.GetString(flags.Selector)
+	recipients := viper.GetStringSlice(flags.Recipient)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(out) == 0 {
+		return fmt.Errorf("please input value for --out flag")
+	}
+
+	if len(recipients) > 0 && len(passphrase) > 0 {
+		return fmt.Errorf("passphrase and recipients are mutually exclusive")
+	}
+
+	if len(recipients) == 0 && len(passphrase) == 0 {
+		passphrase, err = promptNewPassphrase(prompt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(passphrase) > 0 {
+		if err := checkPassphrase(passphrase); err != nil {
+			return err
+		}
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secrets, err := listSecrets(ctx, client, persistentFlags.Project, selector)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].GetName() < secrets[j].GetName()
+	})
+
+	b := &bundle.Bundle{
+		Project:    persistentFlags.Project,
+		CreateTime: time.Now().UTC(),
+		Secrets:    make([]bundle.Secret, 0, len(secrets)),
+	}
+
+	numVersions := 0
+	for _, secret := range secrets {
+		s := bundleSecretFromSecret(secret)
+
+		versions, err := listVersions(ctx, client, secret.GetName(), "state:ENABLED")
+		if err != nil {
+			return err
+		}
+
+		for _, version := range versions {
+			payload, err := accessVersion(ctx, client, version.GetName())
+			if err != nil {
+				return err
+			}
+
+			s.Versions = append(
+				s.Versions,
+				bundle.Version{
+					Version:    path.Base(version.GetName()),
+					CreateTime: version.GetCreateTime().AsTime(),
+					Payload:    payload,
+					Crc32c:     int64(Crc32Sum(payload)),
+				},
+			)
+		}
+
+		numVersions += len(s.Versions)
+		b.Secrets = append(b.Secrets, s)
+	}
+
+	data, err := bundle.Seal(b, passphrase, recipients)
+	if err != nil {
+		return fmt.Errorf("failed to seal bundle: %w", err)
+	}
+
+	if err := writeFilePrivate(out, data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(
+		cmd.ErrOrStderr(),
+		"Backed up %d secrets with %d versions to %s\n",
+		len(b.Secrets),
+		numVersions,
+		out,
+	); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func BackupKeygen(cmd *cobra.Command, args []string) error {
+	_ = viper.BindPFlag(flags.Out, cmd.Flag(flags.Out))
+	out := viper.GetString(flags.Out)
+
+	identity, recipient, err := bundle.NewIdentity()
+	if err != nil {
+		return err
+	}
+
+	if len(out) > 0 {
+		if err := writeFilePrivate(out, []byte(identity+"\n")); err != nil {
+			return fmt.Errorf("failed to write identity: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), recipient); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(cmd.OutOrStdout(), "identity: %s\nrecipient: %s\n", identity, recipient); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func Restore(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Conflict, cmd.Flag(flags.Conflict))
+	_ = viper.BindPFlag(flags.Identity, cmd.Flag(flags.Identity))
+	_ = viper.BindPFlag(flags.CopyTopics, cmd.Flag(flags.CopyTopics))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	filename := args[0]
+	conflict := viper.GetString(flags.Conflict)
+	identityFile := viper.GetString(flags.Identity)
+	copyTopics := viper.GetBool(flags.CopyTopics)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	switch conflict {
+	case ConflictSkip, ConflictOverwrite, ConflictNewVersion:
+	default:
+		return fmt.Errorf("invalid conflict policy %s, valid policies are %v",
+			conflict,
+			[]string{ConflictSkip, ConflictOverwrite, ConflictNewVersion},
+		)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	mode, err := bundle.Mode(data)
+	if err != nil {
+		return err
+	}
+
+	var identity string
+	switch mode {
+	case bundle.ModeRecipient:
+		if len(identityFile) == 0 {
+			return fmt.Errorf("bundle is encrypted for recipients, please input value for --identity flag")
+		}
+
+		b, err := os.ReadFile(identityFile)
+		if err != nil {
+			return fmt.Errorf("failed to read identity: %w", err)
+		}
+		identity = strings.TrimSpace(string(b))
+	case bundle.ModePassphrase:
+		if len(passphrase) == 0 {
+			passphrase, err = promptPassphrase(prompt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	b, err := bundle.Open(data, passphrase, identity)
+	if err != nil {
+		return err
+	}
+
+	// topics are project resources, so they are restored within
+	// the project that was backed up
+	if b.Project == persistentFlags.Project {
+		copyTopics = true
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	results := make([]restoreResult, 0, len(b.Secrets))
+	for i := range b.Secrets {
+		s := &b.Secrets[i]
+
+		if value, ok := s.Labels[app.KeyManagedBy]; !ok || value != app.Name {
+			return fmt.Errorf("bundle secret %s is not managed by this app", s.Name)
+		}
+
+		for _, version := range s.Versions {
+			if version.Crc32c != int64(Crc32Sum(version.Payload)) {
+				return fmt.Errorf("checksum mismatch for bundle secret %s version %s", s.Name, version.Version)
+			}
+		}
+
+		result := restoreResult{Name: s.Name}
+
+		id, err := namespace.ToID(s.Name)
+		if err != nil {
+			return fmt.Errorf("invalid bundle secret name: %w", err)
+		}
+
+		existing, err := client.GetSecret(
+			ctx,
+			&secretmanagerpb.GetSecretRequest{
+				Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, id),
+			},
+		)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to get secret %s: %w", s.Name, err)
+		}
+
+		target := existing
+		var stale []*secretmanagerpb.SecretVersion
+		if existing != nil {
+			if value, ok := existing.GetLabels()[app.KeyManagedBy]; !ok || value != app.Name {
+				return fmt.Errorf("existing secret %s is not being managed by this app", s.Name)
+			}
+
+			switch conflict {
+			case ConflictSkip:
+				result.Action = "skipped"
+				results = append(results, result)
+				continue
+			case ConflictOverwrite:
+				target, stale, err = overwriteSecret(ctx, client, existing, s, copyTopics)
+				if err != nil {
+					return err
+				}
+				result.Action = "overwritten"
+			case ConflictNewVersion:
+				if existing.GetLabels()[app.KeyEncrypted] != s.Labels[app.KeyEncrypted] {
+					return fmt.Errorf("secret %s encryption differs from bundle and this property is immutable", s.Name)
+				}
+				result.Action = "updated"
+			}
+		} else {
+			result.Action = "created"
+		}
+
+		if target == nil {
+			target, err = createSecretFrom(ctx, client, persistentFlags.Project, s.Name, secretFromBundleSecret(s, copyTopics))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, version := range s.Versions {
+			if _, err := addStoredVersion(ctx, client, target.GetName(), version.Payload, false); err != nil {
+				return fmt.Errorf("failed to restore secret %s version %s: %w", s.Name, version.Version, err)
+			}
+			result.Versions++
+		}
+
+		// versions being overwritten are destroyed only after all
+		// bundle versions have been restored
+		for _, version := range stale {
+			if _, err := client.DestroySecretVersion(
+				ctx,
+				&secretmanagerpb.DestroySecretVersionRequest{
+					Name: version.GetName(),
+				},
+			); err != nil {
+				return fmt.Errorf("failed to destroy overwritten version %s of secret %s: %w", path.Base(version.GetName()), s.Name, err)
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		for _, result := range results {
+			if _, err := fmt.Fprintf(
+				cmd.OutOrStdout(),
+				"%s: %s (%d versions)\n",
+				result.Name,
+				result.Action,
+				result.Versions,
+			); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Action", "Versions"})
+		for _, result := range results {
+			table.Append([]string{result.Name, result.Action, fmt.Sprintf("%d", result.Versions)})
+		}
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package run
+
+import (
+	"context"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"google.golang.org/api/option"
+)
+
+type clientOptionsKey struct{}
+
+// WithClientOptions returns a context carrying options that commands
+// use when creating secret manager client, which allows pointing them
+// to another endpoint, for instance in tests
+func WithClientOptions(ctx context.Context, opts ...option.ClientOption) context.Context {
+	return context.WithValue(ctx, clientOptionsKey{}, opts)
+}
+
+// newClient creates secret manager client using options carried by
+// context, if any
+func newClient(ctx context.Context) (*secretmanager.Client, error) {
+	opts, _ := ctx.Value(clientOptionsKey{}).([]option.ClientOption)
+	return secretmanager.NewClient(ctx, opts...)
+}
@@ -0,0 +1,49 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/clipboard"
+	"github.com/kubetrail/mksecret/pkg/prompter"
+	"github.com/spf13/cobra"
+)
+
+// clipValue copies value to terminal clipboard and overwrites it after
+// timeout, or sooner if interrupted. Clipboard is not overwritten when
+// timeout is zero.
+func clipValue(cmd *cobra.Command, prompt prompter.Prompter, value []byte, timeout time.Duration) error {
+	tty, err := clipboard.Terminal()
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+
+	if err := clipboard.Copy(tty, value); err != nil {
+		return err
+	}
+
+	if timeout <= 0 {
+		return prompt.Print("Copied to clipboard")
+	}
+
+	if err := prompt.Print(fmt.Sprintf("Copied to clipboard, clearing in %s", timeout)); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	return clipboard.Clear(tty)
+}
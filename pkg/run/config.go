@@ -0,0 +1,233 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubetrail/mksecret/pkg/config"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// contextInfo is a context as reported by get-contexts
+type contextInfo struct {
+	Name    string         `json:"name" yaml:"name"`
+	Current bool           `json:"current" yaml:"current"`
+	Context config.Context `json:"context" yaml:"context"`
+}
+
+// openConfig opens config file in use, else the default config file
+func openConfig() (*config.File, error) {
+	name := viper.ConfigFileUsed()
+	if len(name) == 0 {
+		var err error
+		name, err = config.DefaultFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return config.Open(name)
+}
+
+// activeContext returns name of context from flag, else current
+// context of config file
+func activeContext(cmd *cobra.Command, f *config.File) string {
+	if name, _ := cmd.Root().PersistentFlags().GetString(flags.Context); len(name) > 0 {
+		return name
+	}
+
+	return f.CurrentContext()
+}
+
+func ConfigUseContext(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	f, err := openConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := f.UseContext(name); err != nil {
+		return err
+	}
+
+	if err := f.Save(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Switched to context %s\n", name); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func ConfigGetContexts(cmd *cobra.Command, args []string) error {
+	persistentFlags := getPersistentFlags(cmd)
+
+	f, err := openConfig()
+	if err != nil {
+		return err
+	}
+
+	contexts, err := f.Contexts()
+	if err != nil {
+		return err
+	}
+
+	active := activeContext(cmd, f)
+
+	out := make([]contextInfo, 0, len(contexts))
+	for _, name := range config.SortedNames(contexts) {
+		out = append(
+			out,
+			contextInfo{
+				Name:    name,
+				Current: name == active,
+				Context: contexts[name],
+			},
+		)
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		for _, c := range out {
+			marker := " "
+			if c.Current {
+				marker = "*"
+			}
+
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", marker, c.Name); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Current", "Name", "Project", "Backend", "Encrypt", "Replication", "Output Format"})
+		for _, c := range out {
+			marker := ""
+			if c.Current {
+				marker = "*"
+			}
+
+			encrypt := ""
+			if c.Context.Encrypt != nil {
+				encrypt = strconv.FormatBool(*c.Context.Encrypt)
+			}
+
+			table.Append(
+				[]string{
+					marker,
+					c.Name,
+					c.Context.Project,
+					c.Context.Backend,
+					encrypt,
+					strings.Join(c.Context.Replication, ","),
+					c.Context.OutputFormat,
+				},
+			)
+		}
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
+
+func ConfigSet(cmd *cobra.Command, args []string) error {
+	property := args[0]
+	value := ""
+	if len(args) > 1 {
+		value = args[1]
+	}
+
+	f, err := openConfig()
+	if err != nil {
+		return err
+	}
+
+	// property is current-context, contexts.NAME.KEY or a key of the
+	// context in use
+	switch parts := strings.SplitN(property, ".", 3); {
+	case property == flags.CurrentContext:
+		err = f.UseContext(value)
+	case len(parts) == 3 && parts[0] == flags.Contexts:
+		err = f.Set(parts[1], parts[2], value)
+	case len(parts) == 1:
+		name := activeContext(cmd, f)
+		if len(name) == 0 {
+			return fmt.Errorf("no context is in use, please provide %s.NAME.%s", flags.Contexts, property)
+		}
+		err = f.Set(name, property, value)
+	default:
+		return fmt.Errorf("invalid property %s, need %s, %s.NAME.KEY or KEY", property, flags.CurrentContext, flags.Contexts)
+	}
+	if err != nil {
+		return err
+	}
+
+	return f.Save()
+}
+
+func ConfigView(cmd *cobra.Command, args []string) error {
+	persistentFlags := getPersistentFlags(cmd)
+
+	f, err := openConfig()
+	if err != nil {
+		return err
+	}
+
+	b, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if persistentFlags.OutputFormat == flags.OutputFormatJson {
+		var out interface{}
+		if err := yaml.Unmarshal(b, &out); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+
+		jb, err := json.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+
+		return nil
+	}
+
+	if _, err := cmd.OutOrStdout().Write(b); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
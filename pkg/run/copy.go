@@ -0,0 +1,398 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// labelsEqual compares two label sets
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replicateSecret makes destination secret match the source secret by
+// creating it with same metadata, updating its labels and adding source
+// payloads byte-for-byte as new versions. When allVersions is set, enabled
+// source versions missing after the enabled destination versions are
+// copied, else only the latest enabled version is copied if it differs
+// from the latest destination version. Topics are copied only when
+// copyTopics is set. Nothing is changed on dry run.
+func replicateSecret(
+	ctx context.Context,
+	client *secretmanager.Client,
+	src *secretmanagerpb.Secret,
+	dstProject, dstName string,
+	allVersions, copyTopics, dryRun bool,
+) (*planChange, error) {
+	srcName := logicalName(src)
+
+	dstID, err := namespace.ToID(dstName)
+	if err != nil {
+		return nil, err
+	}
+
+	srcVersions, err := listVersions(ctx, client, src.GetName(), "state:ENABLED")
+	if err != nil {
+		return nil, err
+	}
+
+	if !allVersions && len(srcVersions) > 0 {
+		srcVersions = srcVersions[len(srcVersions)-1:]
+	}
+
+	dst, err := client.GetSecret(
+		ctx,
+		&secretmanagerpb.GetSecretRequest{
+			Name: fmt.Sprintf("projects/%s/secrets/%s", dstProject, dstID),
+		},
+	)
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("failed to get secret %s: %w", dstName, err)
+	}
+
+	if dst == nil {
+		change := &planChange{
+			Action: planActionCreate,
+			Name:   dstName,
+			Fields: []string{"labels", fmt.Sprintf("versions=%d", len(srcVersions))},
+		}
+
+		if dryRun {
+			return change, nil
+		}
+
+		template := src
+		if !copyTopics {
+			template = proto.Clone(src).(*secretmanagerpb.Secret)
+			template.Topics = nil
+		}
+
+		dst, err = createSecretFrom(ctx, client, dstProject, dstName, template)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range srcVersions {
+			payload, err := accessVersion(ctx, client, version.GetName())
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := addStoredVersion(ctx, client, dst.GetName(), payload, false); err != nil {
+				return nil, fmt.Errorf("failed to copy version %s of %s: %w",
+					path.Base(version.GetName()), srcName, err)
+			}
+		}
+
+		return change, nil
+	}
+
+	if value, ok := dst.GetLabels()[app.KeyManagedBy]; !ok || value != app.Name {
+		return nil, fmt.Errorf("destination secret %s is not being managed by this app", dstName)
+	}
+
+	if dst.GetLabels()[app.KeyEncrypted] != src.GetLabels()[app.KeyEncrypted] {
+		return &planChange{
+			Action: planActionConflict,
+			Name:   dstName,
+			Reason: "encryption is immutable",
+		}, nil
+	}
+
+	var fields []string
+	labels := namespaceLabels(src.GetLabels(), dstName)
+	if !labelsEqual(labels, dst.GetLabels()) {
+		fields = append(fields, "labels")
+	}
+
+	// payloads of source versions to be added to destination
+	var payloads [][]byte
+	if len(srcVersions) > 0 {
+		dstVersions, err := listVersions(ctx, client, dst.GetName(), "state:ENABLED")
+		if err != nil {
+			return nil, err
+		}
+
+		if allVersions {
+			payloads, err = missingPayloads(ctx, client, srcVersions, dstVersions)
+			if err != nil {
+				return nil, err
+			}
+
+			if payloads == nil {
+				return &planChange{
+					Action: planActionConflict,
+					Name:   dstName,
+					Reason: "destination versions differ from source versions",
+				}, nil
+			}
+
+			if len(payloads) > 0 {
+				fields = append(fields, fmt.Sprintf("versions=%d", len(payloads)))
+			}
+		} else {
+			payload, err := accessVersion(ctx, client, srcVersions[len(srcVersions)-1].GetName())
+			if err != nil {
+				return nil, err
+			}
+
+			var dstPayload []byte
+			if len(dstVersions) > 0 {
+				dstPayload, err = accessVersion(ctx, client, dstVersions[len(dstVersions)-1].GetName())
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if len(dstVersions) == 0 || !bytes.Equal(payload, dstPayload) {
+				fields = append(fields, "value")
+				payloads = [][]byte{payload}
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	change := &planChange{
+		Action: planActionUpdate,
+		Name:   dstName,
+		Fields: fields,
+	}
+
+	if dryRun {
+		return change, nil
+	}
+
+	if fields[0] == "labels" {
+		if _, err := client.UpdateSecret(
+			ctx,
+			&secretmanagerpb.UpdateSecretRequest{
+				Secret: &secretmanagerpb.Secret{
+					Name:   dst.GetName(),
+					Labels: labels,
+				},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}},
+			},
+		); err != nil {
+			return nil, fmt.Errorf("failed to update labels of %s: %w", dstName, err)
+		}
+	}
+
+	for _, payload := range payloads {
+		if _, err := addStoredVersion(ctx, client, dst.GetName(), payload, false); err != nil {
+			return nil, fmt.Errorf("failed to copy version of %s: %w", srcName, err)
+		}
+	}
+
+	return change, nil
+}
+
+// missingPayloads returns payloads of source versions that follow
+// destination versions when payloads of destination versions match
+// those of the first source versions in order. Nil is returned when
+// they do not match, since versions cannot be inserted.
+func missingPayloads(
+	ctx context.Context,
+	client *secretmanager.Client,
+	srcVersions, dstVersions []*secretmanagerpb.SecretVersion,
+) ([][]byte, error) {
+	if len(dstVersions) > len(srcVersions) {
+		return nil, nil
+	}
+
+	payloads := make([][]byte, 0, len(srcVersions)-len(dstVersions))
+	for i, version := range srcVersions {
+		payload, err := accessVersion(ctx, client, version.GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		if i >= len(dstVersions) {
+			payloads = append(payloads, payload)
+			continue
+		}
+
+		dstPayload, err := accessVersion(ctx, client, dstVersions[i].GetName())
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(payload, dstPayload) {
+			return nil, nil
+		}
+	}
+
+	return payloads, nil
+}
+
+func Copy(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.ToProject, cmd.Flag(flags.ToProject))
+	_ = viper.BindPFlag(flags.ToName, cmd.Flag(flags.ToName))
+	_ = viper.BindPFlag(flags.AllVersions, cmd.Flag(flags.AllVersions))
+	_ = viper.BindPFlag(flags.CopyTopics, cmd.Flag(flags.CopyTopics))
+	_ = viper.BindPFlag(flags.DryRun, cmd.Flag(flags.DryRun))
+
+	name := args[0]
+	toProject := viper.GetString(flags.ToProject)
+	toName := viper.GetString(flags.ToName)
+	allVersions := viper.GetBool(flags.AllVersions)
+	copyTopics := viper.GetBool(flags.CopyTopics)
+	dryRun := viper.GetBool(flags.DryRun)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// topics are project resources, so they are copied within a project
+	if len(toProject) == 0 || toProject == persistentFlags.Project {
+		toProject = persistentFlags.Project
+		copyTopics = true
+	}
+
+	if len(toName) == 0 {
+		toName = name
+	}
+
+	if toProject == persistentFlags.Project && toName == name {
+		return fmt.Errorf("source and destination are the same, please input --to-project or --to-name")
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	src, err := getManagedSecret(ctx, client, persistentFlags.Project, name)
+	if err != nil {
+		return err
+	}
+
+	change, err := replicateSecret(ctx, client, src, toProject, toName, allVersions, copyTopics, dryRun)
+	if err != nil {
+		return err
+	}
+
+	changes := make([]planChange, 0, 1)
+	if change != nil {
+		changes = append(changes, *change)
+	}
+
+	if err := writePlan(cmd.OutOrStdout(), persistentFlags.OutputFormat, changes); err != nil {
+		return err
+	}
+
+	if change != nil && change.Action == planActionConflict {
+		return fmt.Errorf("cannot copy, secret %s has a conflict: %s", change.Name, change.Reason)
+	}
+
+	return nil
+}
+
+func Sync(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.FromProject, cmd.Flag(flags.FromProject))
+	_ = viper.BindPFlag(flags.ToProject, cmd.Flag(flags.ToProject))
+	_ = viper.BindPFlag(flags.Selector, cmd.Flag(flags.Selector))
+	_ = viper.BindPFlag(flags.AllVersions, cmd.Flag(flags.AllVersions))
+	_ = viper.BindPFlag(flags.CopyTopics, cmd.Flag(flags.CopyTopics))
+	_ = viper.BindPFlag(flags.DryRun, cmd.Flag(flags.DryRun))
+
+	fromProject := viper.GetString(flags.FromProject)
+	toProject := viper.GetString(flags.ToProject)
+	selector := viper.GetString(flags.Selector)
+	allVersions := viper.GetBool(flags.AllVersions)
+	copyTopics := viper.GetBool(flags.CopyTopics)
+	dryRun := viper.GetBool(flags.DryRun)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(fromProject) == 0 {
+		fromProject = persistentFlags.Project
+	}
+
+	if len(toProject) == 0 {
+		return fmt.Errorf("please input value for --to-project flag")
+	}
+
+	if fromProject == toProject {
+		return fmt.Errorf("source and destination projects are the same")
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secrets, err := listSecrets(ctx, client, fromProject, selector)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].GetName() < secrets[j].GetName()
+	})
+
+	changes := make([]planChange, 0, len(secrets))
+	conflicts := 0
+	for _, secret := range secrets {
+		change, err := replicateSecret(ctx, client, secret, toProject, logicalName(secret), allVersions, copyTopics, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if change != nil {
+			if change.Action == planActionConflict {
+				conflicts++
+			}
+			changes = append(changes, *change)
+		}
+	}
+
+	if err := writePlan(cmd.OutOrStdout(), persistentFlags.OutputFormat, changes); err != nil {
+		return err
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("%d secrets could not be synced due to conflicts", conflicts)
+	}
+
+	return nil
+}
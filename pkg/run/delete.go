@@ -4,13 +4,12 @@ import (
 	"fmt"
 	"path/filepath"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"github.com/kubetrail/mksecret/pkg/app"
 	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
-	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func Delete(cmd *cobra.Command, args []string) error {
@@ -19,7 +18,6 @@ func Delete(cmd *cobra.Command, args []string) error {
 
 	_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
 
-	name := args[0]
 	force := viper.GetBool(flags.Force)
 
 	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
@@ -27,17 +25,31 @@ func Delete(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	name, err := secretName(ctx, cmd, client, persistentFlags.Project, args)
+	if err != nil {
+		return err
+	}
+
 	if len(name) == 0 {
 		return fmt.Errorf("please provide name of the password")
 	}
 
 	if !force {
-		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Type secret name to delete: "); err != nil {
-			return fmt.Errorf("failed to write to output: %w", err)
+		prompt, err := newPrompter(cmd, false)
+		if err != nil {
+			return err
 		}
-		var input string
-		if _, err := fmt.Fscanln(cmd.InOrStdin(), &input); err != nil {
-			return fmt.Errorf("failed to read from input: %w", err)
+
+		input, err := prompt.Line("Type secret name to delete")
+		if err != nil {
+			return err
 		}
 
 		if input != name {
@@ -45,21 +57,15 @@ func Delete(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
-		return fmt.Errorf("invalid name, need DNS1123Label format: %v", errs)
-	}
-
-	// Create the client.
-	client, err := secretmanager.NewClient(ctx)
+	id, err := namespace.ToID(name)
 	if err != nil {
-		return fmt.Errorf("failed to create secret manager client: %w", err)
+		return err
 	}
-	defer client.Close()
 
 	secret, err := client.GetSecret(
 		ctx,
 		&secretmanagerpb.GetSecretRequest{
-			Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, name),
+			Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, id),
 		},
 	)
 	if err != nil {
@@ -73,7 +79,7 @@ func Delete(cmd *cobra.Command, args []string) error {
 
 	// Build the request.
 	deleteRequest := &secretmanagerpb.DeleteSecretRequest{
-		Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, name),
+		Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, id),
 	}
 
 	// Call the API.
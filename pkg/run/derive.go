@@ -0,0 +1,205 @@
+package run
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubetrail/bip32/pkg/keys"
+	"github.com/kubetrail/bip39/pkg/seeds"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// deriveKey derives a BIP32 key from a stored mnemonic, extended
+// private key or hex encoded seed
+func deriveKey(value *secretValue, bip39Passphrase, network, derivationPath string) (*keys.Key, error) {
+	payload := strings.TrimSpace(string(value.Payload))
+
+	if value.Labels[app.KeyType] == app.TypeMnemonic {
+		key, err := keys.New(seeds.New(payload, bip39Passphrase), network, derivationPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key from mnemonic: %w", err)
+		}
+		return key, nil
+	}
+
+	if keys.Validate(payload) == nil {
+		key, err := keys.Derive(payload, derivationPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key from extended key: %w", err)
+		}
+		return key, nil
+	}
+
+	seed, err := hex.DecodeString(strings.TrimPrefix(payload, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("secret %s is neither a mnemonic, an extended key nor a hex seed", value.Name)
+	}
+
+	key, err := keys.New(seed, network, derivationPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key from seed: %w", err)
+	}
+
+	return key, nil
+}
+
+func Derive(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Version, cmd.Flag(flags.Version))
+	_ = viper.BindPFlag(flags.Network, cmd.Flag(flags.Network))
+	_ = viper.BindPFlag(flags.Bip39Passphrase, cmd.Flag(flags.Bip39Passphrase))
+	_ = viper.BindPFlag(flags.StoreAs, cmd.Flag(flags.StoreAs))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	name := args[0]
+	version := viper.GetString(flags.Version)
+	// derivation path is not bound to viper since its key would
+	// otherwise be overridden by PATH env. var.
+	derivationPath, err := cmd.Flags().GetString(flags.DerivationPath)
+	if err != nil {
+		return fmt.Errorf("failed to get derivation path: %w", err)
+	}
+	network := viper.GetString(flags.Network)
+	bip39Passphrase := viper.GetString(flags.Bip39Passphrase)
+	storeAs := viper.GetString(flags.StoreAs)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	value, err := reader.read(ctx, name, version)
+	if err != nil {
+		return err
+	}
+
+	key, err := deriveKey(value, bip39Passphrase, network, derivationPath)
+	if err != nil {
+		return err
+	}
+
+	// seed is not part of derived key output
+	key.Seed = ""
+
+	if len(storeAs) > 0 {
+		writer := &secretWriter{
+			client:  client,
+			project: persistentFlags.Project,
+		}
+
+		secret, encrypt, err := writer.ensure(ctx, storeAs, value.Encrypted, nil)
+		if err != nil {
+			return err
+		}
+
+		var aesKey []byte
+		if encrypt {
+			// derived key is encrypted using same passphrase as its source
+			if len(reader.passphrase) == 0 {
+				reader.passphrase, err = promptNewPassphrase(prompt)
+				if err != nil {
+					return err
+				}
+			}
+
+			// policy applies when the passphrase differs from that of
+			// existing versions, same as when setting a value
+			newPassphrase, err := isNewPassphrase(ctx, client, secret, reader.passphrase)
+			if err != nil {
+				return err
+			}
+
+			if newPassphrase {
+				if err := checkPassphrase(reader.passphrase, []byte(key.XPrv)); err != nil {
+					return err
+				}
+			}
+
+			aesKey, err = crypto.NewAesKeyFromPassphrase([]byte(reader.passphrase))
+			if err != nil {
+				return fmt.Errorf("failed to generate new AES key: %w", err)
+			}
+		}
+
+		if _, err := writer.add(ctx, secret, []byte(key.XPrv), aesKey); err != nil {
+			return err
+		}
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "xprv: %s\nxpub: %s\naddr: %s\n",
+			key.XPrv, key.XPub, key.Addr); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Path", "XPub", "Addr"})
+		table.Append(
+			[]string{
+				name,
+				key.DerivationPath,
+				key.XPub,
+				key.Addr,
+			},
+		)
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
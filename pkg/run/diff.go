@@ -0,0 +1,203 @@
+package run
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/diff"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// versionDiff is the result of comparing two versions of a secret
+type versionDiff struct {
+	Name       string             `json:"name" yaml:"name"`
+	OldVersion string             `json:"oldVersion" yaml:"oldVersion"`
+	NewVersion string             `json:"newVersion" yaml:"newVersion"`
+	Identical  bool               `json:"identical" yaml:"identical"`
+	OldSha256  string             `json:"oldSha256,omitempty" yaml:"oldSha256,omitempty"`
+	NewSha256  string             `json:"newSha256,omitempty" yaml:"newSha256,omitempty"`
+	Fields     []diff.FieldChange `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Unified    string             `json:"unified,omitempty" yaml:"unified,omitempty"`
+	Note       string             `json:"note,omitempty" yaml:"note,omitempty"`
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func Diff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Redacted, cmd.Flag(flags.Redacted))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	name := args[0]
+	redacted := viper.GetBool(flags.Redacted)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	oldValue, err := reader.read(ctx, name, args[1])
+	if err != nil {
+		return err
+	}
+
+	newValue, err := reader.read(ctx, name, args[2])
+	if err != nil {
+		return err
+	}
+
+	result := &versionDiff{
+		Name:       name,
+		OldVersion: oldValue.Version,
+		NewVersion: newValue.Version,
+		Identical:  bytes.Equal(oldValue.Payload, newValue.Payload),
+	}
+
+	switch {
+	case redacted:
+		result.OldSha256 = sha256Hex(oldValue.Payload)
+		result.NewSha256 = sha256Hex(newValue.Payload)
+	case result.Identical:
+	case diff.IsJson(oldValue.Payload) && diff.IsJson(newValue.Payload):
+		result.Fields, err = diff.Fields(oldValue.Payload, newValue.Payload)
+		if err != nil {
+			return err
+		}
+	default:
+		bb := new(bytes.Buffer)
+		if err := diff.Unified(
+			bb,
+			fmt.Sprintf("%s@%s", name, oldValue.Version),
+			fmt.Sprintf("%s@%s", name, newValue.Version),
+			string(oldValue.Payload),
+			string(newValue.Payload),
+		); err != nil {
+			return err
+		}
+		result.Unified = bb.String()
+	}
+
+	// values such as JSON with keys in another order or with a trailing
+	// newline differ without any changed field or line
+	if !redacted && !result.Identical && len(result.Fields) == 0 && len(result.Unified) == 0 {
+		result.Note = "values differ in whitespace or formatting only"
+	}
+
+	status := "differs"
+	if result.Identical {
+		status = "identical"
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		w := cmd.OutOrStdout()
+		switch {
+		case len(result.Note) > 0:
+			if _, err := fmt.Fprintln(w, result.Note); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		case redacted || result.Identical:
+			if _, err := fmt.Fprintln(w, status); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+			if redacted {
+				if _, err := fmt.Fprintf(w, "%s@%s sha256:%s\n%s@%s sha256:%s\n",
+					name, result.OldVersion, result.OldSha256,
+					name, result.NewVersion, result.NewSha256); err != nil {
+					return fmt.Errorf("failed to write to output: %w", err)
+				}
+			}
+		case result.Fields != nil:
+			for _, change := range result.Fields {
+				if _, err := fmt.Fprintf(w, "~ %s\n", change.Path); err != nil {
+					return fmt.Errorf("failed to write to output: %w", err)
+				}
+				if len(change.Old) > 0 {
+					if _, err := fmt.Fprintf(w, "  - %s\n", change.Old); err != nil {
+						return fmt.Errorf("failed to write to output: %w", err)
+					}
+				}
+				if len(change.New) > 0 {
+					if _, err := fmt.Fprintf(w, "  + %s\n", change.New); err != nil {
+						return fmt.Errorf("failed to write to output: %w", err)
+					}
+				}
+			}
+		default:
+			if _, err := fmt.Fprint(w, result.Unified); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Old Version", "New Version", "Status"})
+		table.Append(
+			[]string{
+				name,
+				result.OldVersion,
+				result.NewVersion,
+				status,
+			},
+		)
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
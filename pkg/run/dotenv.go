@@ -0,0 +1,292 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/dotenv"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// dotenvMapping reports how a dotenv key was mapped to a secret
+type dotenvMapping struct {
+	Key     string `json:"key,omitempty" yaml:"key,omitempty"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+func ImportDotenv(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Encrypt, cmd.Flag(flags.Encrypt))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	filename := args[0]
+	encrypt := viper.GetBool(flags.Encrypt)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	// enforce encryption if passphrase is explicitly provided
+	if len(passphrase) > 0 {
+		encrypt = true
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open dotenv file: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := dotenv.Parse(f)
+	if err != nil {
+		return fmt.Errorf("failed to parse dotenv file: %w", err)
+	}
+
+	// validate all names before writing anything
+	mappings := make([]dotenvMapping, 0, len(entries))
+	keysByName := make(map[string]string)
+	for _, entry := range entries {
+		name, err := dotenv.KeyToName(entry.Key)
+		if err != nil {
+			return err
+		}
+
+		if key, ok := keysByName[name]; ok {
+			return fmt.Errorf("keys %s and %s both map to secret name %s", key, entry.Key, name)
+		}
+		keysByName[name] = entry.Key
+
+		mappings = append(mappings, dotenvMapping{Key: entry.Key, Name: name})
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	writer := &secretWriter{
+		client:  client,
+		project: persistentFlags.Project,
+	}
+
+	var key []byte
+	checked := false
+	for i, entry := range entries {
+		secret, encrypted, err := writer.ensure(ctx, mappings[i].Name, encrypt, nil)
+		if err != nil {
+			return fmt.Errorf("failed to import key %s: %w", entry.Key, err)
+		}
+
+		var entryKey []byte
+		if encrypted {
+			if key == nil {
+				if len(passphrase) == 0 {
+					passphrase, err = promptNewPassphrase(prompt)
+					if err != nil {
+						return err
+					}
+				}
+
+				key, err = crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+				if err != nil {
+					return fmt.Errorf("failed to generate new AES key: %w", err)
+				}
+			}
+			entryKey = key
+
+			// policy applies once passphrase is new for any secret
+			if !checked {
+				newPassphrase, err := isNewPassphrase(ctx, client, secret, passphrase)
+				if err != nil {
+					return err
+				}
+
+				if newPassphrase {
+					values := make([][]byte, 0, len(entries))
+					for _, entry := range entries {
+						values = append(values, []byte(entry.Value))
+					}
+
+					if err := checkPassphrase(passphrase, values...); err != nil {
+						return err
+					}
+					checked = true
+				}
+			}
+		}
+
+		version, err := writer.add(ctx, secret, []byte(entry.Value), entryKey)
+		if err != nil {
+			return fmt.Errorf("failed to import key %s: %w", entry.Key, err)
+		}
+
+		mappings[i].Version = path.Base(version.GetName())
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		for _, mapping := range mappings {
+			if _, err := fmt.Fprintf(
+				cmd.OutOrStdout(),
+				"%s -> %s (version %s)\n",
+				mapping.Key,
+				mapping.Name,
+				mapping.Version,
+			); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(mappings)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(mappings)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Key", "Name", "Version"})
+		for _, mapping := range mappings {
+			table.Append([]string{mapping.Key, mapping.Name, mapping.Version})
+		}
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
+
+func ExportDotenv(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Selector, cmd.Flag(flags.Selector))
+	_ = viper.BindPFlag(flags.Out, cmd.Flag(flags.Out))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	selector := viper.GetString(flags.Selector)
+	out := viper.GetString(flags.Out)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secrets, err := listSecrets(ctx, client, persistentFlags.Project, selector)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		names = append(names, logicalName(secret))
+	}
+	sort.Strings(names)
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	// names such as team/app/db and team-app-db map to the same key
+	keys := make(map[string]string, len(names))
+	for _, name := range names {
+		key := dotenv.NameToKey(name)
+		if other, ok := keys[key]; ok {
+			return fmt.Errorf("secrets %s and %s both map to key %s", other, name, key)
+		}
+		keys[key] = name
+	}
+
+	entries := make([]dotenv.Entry, 0, len(names))
+	for _, name := range names {
+		value, err := reader.read(ctx, name, "latest")
+		if err != nil {
+			return err
+		}
+
+		entries = append(
+			entries,
+			dotenv.Entry{
+				Key:   dotenv.NameToKey(name),
+				Value: string(value.Payload),
+			},
+		)
+	}
+
+	bb := new(bytes.Buffer)
+	if err := dotenv.Format(bb, entries); err != nil {
+		return fmt.Errorf("failed to format dotenv output: %w", err)
+	}
+
+	if len(out) == 0 {
+		if _, err := cmd.OutOrStdout().Write(bb.Bytes()); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+		return nil
+	}
+
+	if err := writeFilePrivate(out, bb.Bytes()); err != nil {
+		return fmt.Errorf("failed to write dotenv file: %w", err)
+	}
+
+	return nil
+}
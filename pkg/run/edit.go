@@ -0,0 +1,348 @@
+package run
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/diff"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/kubetrail/mksecret/pkg/sshkeys"
+	"github.com/mr-tron/base58"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// privateTempDir creates a directory accessible only by current user,
+// preferably on tmpfs so that plaintext never reaches the disk
+func privateTempDir() (string, error) {
+	parent := os.TempDir()
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() {
+		parent = "/dev/shm"
+	}
+
+	dir, err := os.MkdirTemp(parent, "mksecret-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	if err := os.Chmod(dir, 0700); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to set temp dir permissions: %w", err)
+	}
+
+	return dir, nil
+}
+
+// shredDir overwrites all files in dir, including swap and backup
+// files left by editors, with random data before removing the dir
+func shredDir(dir string) error {
+	if err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		return shredFile(name)
+	}); err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("failed to shred temp files: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove temp dir: %w", err)
+	}
+
+	return nil
+}
+
+// shredFile overwrites a file with random data and syncs it
+func shredFile(name string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(f, rand.Reader, fi.Size()); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// runEditor opens file in editor from VISUAL or EDITOR env. var
+// falling back to vi. Editor command may contain arguments.
+func runEditor(cmd *cobra.Command, file string) error {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+
+	c := exec.CommandContext(cmd.Context(), "sh", "-c", editor+` "$1"`, "sh", file)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = cmd.ErrOrStderr()
+
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %s: %w", editor, err)
+	}
+
+	return nil
+}
+
+// validateEdited checks that an edited value is still valid for its
+// type. Values that were JSON documents must remain valid JSON.
+func validateEdited(original, edited []byte, secretType string) error {
+	switch secretType {
+	case app.TypeTls:
+		_, err := validateTlsBundle(edited)
+		return err
+	case app.TypeSshKey:
+		_, err := sshkeys.Validate(edited)
+		return err
+	}
+
+	if diff.IsJson(original) && !json.Valid(edited) {
+		return fmt.Errorf("edited value is not valid json")
+	}
+
+	return nil
+}
+
+func Edit(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	name, err := secretName(ctx, cmd, client, persistentFlags.Project, args)
+	if err != nil {
+		return err
+	}
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	value, err := reader.read(ctx, name, "latest")
+	if err != nil {
+		return err
+	}
+
+	secretType := value.Labels[app.KeyType]
+
+	dir, err := privateTempDir()
+	if err != nil {
+		return err
+	}
+	defer shredDir(dir)
+
+	ext := ".txt"
+	if diff.IsJson(value.Payload) {
+		ext = ".json"
+	}
+
+	// temp file is named after secret ID since names may contain
+	// path separators
+	id, err := namespace.ToID(name)
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, id+ext)
+	if err := os.WriteFile(file, value.Payload, 0600); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	var edited []byte
+	for {
+		if err := runEditor(cmd, file); err != nil {
+			return err
+		}
+
+		edited, err = os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read temp file: %w", err)
+		}
+
+		err = validateEdited(value.Payload, edited, secretType)
+		if err == nil {
+			break
+		}
+
+		answer, promptErr := prompt.Line(fmt.Sprintf("%v, edit again? [y/N]", err))
+		if promptErr != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			return fmt.Errorf("%w, no new version was added", err)
+		}
+	}
+
+	if sha256.Sum256(edited) == sha256.Sum256(value.Payload) {
+		if err := prompt.Print("No changes, no new version was added"); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	secret, err := getManagedSecret(ctx, client, persistentFlags.Project, name)
+	if err != nil {
+		return err
+	}
+
+	writer := &secretWriter{
+		client:  client,
+		project: persistentFlags.Project,
+	}
+
+	// edited value is encrypted the same way as the value it replaces
+	var version *secretmanagerpb.SecretVersion
+	if value.Encrypted && len(reader.passphrase) == 0 {
+		keyAgent, err := agentClient()
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := agentEncrypt(ctx, client, keyAgent, secret.GetName()+"/versions/"+value.Version, edited)
+		if err != nil {
+			return err
+		}
+
+		version, err = addStoredVersion(ctx, client, secret.GetName(), []byte(base58.Encode(ciphertext)), false)
+		if err != nil {
+			return err
+		}
+	} else {
+		var key []byte
+		if value.Encrypted {
+			key, err = crypto.NewAesKeyFromPassphrase([]byte(reader.passphrase))
+			if err != nil {
+				return fmt.Errorf("failed to generate new AES key: %w", err)
+			}
+		}
+
+		version, err = writer.add(ctx, secret, edited, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if secretType == app.TypeTls {
+		notAfter, err := validateTlsBundle(edited)
+		if err != nil {
+			return err
+		}
+
+		if _, err := writer.setLabels(
+			ctx,
+			secret,
+			map[string]string{app.KeyNotAfter: notAfterLabel(notAfter)},
+		); err != nil {
+			return err
+		}
+	}
+
+	// verify the new version can be read back as edited
+	stored, err := reader.read(ctx, name, path.Base(version.GetName()))
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(stored.Payload, edited) {
+		return fmt.Errorf("stored value of version %s does not match edited value", path.Base(version.GetName()))
+	}
+
+	out := struct {
+		Name    string `json:"name" yaml:"name"`
+		Version string `json:"version" yaml:"version"`
+	}{
+		Name:    name,
+		Version: path.Base(version.GetName()),
+	}
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s@%s\n", out.Name, out.Version); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(out)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Version"})
+		table.Append([]string{out.Name, out.Version})
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// forwardedSignals are relayed from this process to the child process
+var forwardedSignals = []os.Signal{
+	syscall.SIGTERM,
+	syscall.SIGHUP,
+}
+
+// terminalSignals are sent by terminal to the whole foreground process
+// group, hence reach the child directly and are only caught here so
+// that this process outlives the child. signal.Ignore is not used since
+// ignored signals would be inherited as ignored by the child.
+var terminalSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGQUIT,
+}
+
+// ExitError reports exit code of a child process that exited with
+// a failure so that it can be propagated as exit code of this process
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
+func Exec(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Env, cmd.Flag(flags.Env))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	envs := viper.GetStringSlice(flags.Env)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("please provide a command to execute")
+	}
+
+	refs := make([]*secretRef, 0, len(envs))
+	for _, env := range envs {
+		ref, err := parseSecretRef(env)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	environ := os.Environ()
+
+	if len(refs) > 0 {
+		// Create the client.
+		client, err := newClient(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create secret manager client: %w", err)
+		}
+
+		reader := &secretReader{
+			client:     client,
+			project:    persistentFlags.Project,
+			passphrase: passphrase,
+			prompt:     prompt,
+		}
+
+		for _, ref := range refs {
+			value, err := reader.read(ctx, ref.name, ref.version)
+			if err != nil {
+				_ = client.Close()
+				return fmt.Errorf("failed to resolve env %s: %w", ref.key, err)
+			}
+
+			environ = append(environ, fmt.Sprintf("%s=%s", ref.key, value.Payload))
+		}
+
+		// client is not needed while the child process runs
+		_ = client.Close()
+	}
+
+	child := exec.Command(args[0], args[1:]...)
+	child.Env = environ
+	child.Stdin = cmd.InOrStdin()
+	child.Stdout = cmd.OutOrStdout()
+	child.Stderr = cmd.ErrOrStderr()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, terminalSignals...)
+	defer signal.Stop(ignored)
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start command %s: %w", args[0], err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = child.Process.Signal(sig)
+			case <-ignored:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+	close(done)
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if code < 0 {
+				// report child killed by a signal as shells do
+				code = 1
+				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+					code = 128 + int(status.Signal())
+				}
+			}
+
+			// child has already reported its failure
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+			return &ExitError{Code: code}
+		}
+
+		return fmt.Errorf("failed to wait for command %s: %w", args[0], err)
+	}
+
+	return nil
+}
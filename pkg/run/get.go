@@ -3,23 +3,14 @@ package run
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"path"
-	"path/filepath"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
-	"github.com/kubetrail/bip39/pkg/passphrases"
-	"github.com/kubetrail/bip39/pkg/prompts"
 	"github.com/kubetrail/mksecret/pkg/app"
-	"github.com/kubetrail/mksecret/pkg/crypto"
 	"github.com/kubetrail/mksecret/pkg/flags"
-	"github.com/mr-tron/base58"
+	"github.com/kubetrail/mksecret/pkg/namespace"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
 	"gopkg.in/yaml.v3"
-	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func Get(cmd *cobra.Command, args []string) error {
@@ -27,22 +18,27 @@ func Get(cmd *cobra.Command, args []string) error {
 	persistentFlags := getPersistentFlags(cmd)
 
 	_ = viper.BindPFlag(flags.Version, cmd.Flag(flags.Version))
-	_ = viper.BindPFlag(flags.Passphrase, cmd.Flag(flags.Passphrase))
 	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+	_ = viper.BindPFlag(flags.Derive, cmd.Flag(flags.Derive))
+	_ = viper.BindPFlag(flags.Bip39Passphrase, cmd.Flag(flags.Bip39Passphrase))
+	_ = viper.BindPFlag(flags.Clip, cmd.Flag(flags.Clip))
+	_ = viper.BindPFlag(flags.ClipTimeout, cmd.Flag(flags.ClipTimeout))
 
-	name := args[0]
 	version := viper.GetString(flags.Version)
-	passphrase := viper.GetString(flags.Passphrase)
 	noPrompt := viper.GetBool(flags.NoPrompt)
-	encrypted := false
+	derive := viper.GetString(flags.Derive)
+	bip39Passphrase := viper.GetString(flags.Bip39Passphrase)
+	clip := viper.GetBool(flags.Clip)
+	clipTimeout := viper.GetDuration(flags.ClipTimeout)
 
-	prompt, err := prompts.Status()
+	passphrase, err := getPassphrase(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to get prompt status: %w", err)
+		return err
 	}
 
-	if noPrompt {
-		prompt = false
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
 	}
 
 	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
@@ -50,75 +46,56 @@ func Get(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	if len(name) == 0 {
-		return fmt.Errorf("please provide name of the password")
-	}
-
-	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
-		return fmt.Errorf("invalid name, need DNS1123Label format: %v", errs)
-	}
-
 	// Create the client.
-	client, err := secretmanager.NewClient(ctx)
+	client, err := newClient(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create secret manager client: %w", err)
 	}
 	defer client.Close()
 
-	secret, err := client.GetSecret(
-		ctx,
-		&secretmanagerpb.GetSecretRequest{
-			Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, name),
-		},
-	)
+	name, err := secretName(ctx, cmd, client, persistentFlags.Project, args)
 	if err != nil {
-		return fmt.Errorf("failed to get secret: %w", err)
+		return err
 	}
 
-	labels := secret.GetLabels()
-	if value, ok := labels[app.KeyManagedBy]; !ok || value != app.Name {
-		return fmt.Errorf("secret is not being managed by this app")
+	if len(name) == 0 {
+		return fmt.Errorf("please provide name of the password")
 	}
-	if value, ok := labels[app.KeyEncrypted]; ok && value == app.ValueTrue {
-		encrypted = true
+
+	if err := namespace.Validate(name); err != nil {
+		return err
 	}
 
-	// Build the request.
-	accessRequest := &secretmanagerpb.AccessSecretVersionRequest{
-		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", persistentFlags.Project, name, version),
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
 	}
 
-	// Call the API.
-	result, err := client.AccessSecretVersion(ctx, accessRequest)
+	value, err := reader.read(ctx, name, version)
 	if err != nil {
-		return fmt.Errorf("failed to access secret version: %w", err)
+		return err
 	}
 
-	payload := result.Payload.GetData()
+	payload := value.Payload
 
-	if encrypted {
-		if len(passphrase) == 0 {
-			if prompt {
-				passphrase, err = passphrases.Prompt(cmd.OutOrStdout())
-			} else {
-				passphrase, err = passphrases.Prompt(io.Discard)
-			}
+	if len(derive) > 0 {
+		if value.Labels[app.KeyType] != app.TypeMnemonic {
+			return fmt.Errorf("secret %s is not of type %s, cannot derive %s", name, app.TypeMnemonic, derive)
 		}
 
-		key, err := crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+		derived, err := deriveFromMnemonic(string(payload), bip39Passphrase, derive)
 		if err != nil {
-			return fmt.Errorf("failed to generate new AES key: %w", err)
+			return err
 		}
 
-		ciphertext, err := base58.Decode(string(result.Payload.GetData()))
-		if err != nil {
-			return fmt.Errorf("failed to base58 decode stored value: %w", err)
-		}
+		payload = []byte(derived)
+	}
 
-		payload, err = crypto.DecryptWithAesKey(ciphertext, key)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt data: %w", err)
-		}
+	// value is sent to clipboard instead of output
+	if clip {
+		return clipValue(cmd, prompt, payload, clipTimeout)
 	}
 
 	switch persistentFlags.OutputFormat {
@@ -134,7 +111,7 @@ func Get(cmd *cobra.Command, args []string) error {
 				Payload string `json:"payload,omitempty"`
 			}{
 				Name:    name,
-				Version: path.Base(result.GetName()),
+				Version: value.Version,
 				Payload: string(payload),
 			},
 		)
@@ -153,7 +130,7 @@ func Get(cmd *cobra.Command, args []string) error {
 				Payload string `json:"payload,omitempty"`
 			}{
 				Name:    name,
-				Version: path.Base(result.GetName()),
+				Version: value.Version,
 				Payload: string(payload),
 			},
 		)
@@ -170,7 +147,7 @@ func Get(cmd *cobra.Command, args []string) error {
 		table.Append(
 			[]string{
 				name,
-				filepath.Base(result.GetName()),
+				value.Version,
 				string(payload),
 			},
 		)
@@ -0,0 +1,132 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/k8s"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// writeManifest writes a Kubernetes manifest as json or yaml
+// depending on the output format
+func writeManifest(cmd *cobra.Command, outputFormat string, manifest interface{}) error {
+	switch outputFormat {
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatNative, flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(manifest)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	default:
+		return fmt.Errorf("output format %s is not supported for manifests", outputFormat)
+	}
+
+	return nil
+}
+
+func K8sManifest(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Name, cmd.Flag(flags.Name))
+	_ = viper.BindPFlag(flags.Namespace, cmd.Flag(flags.Namespace))
+	_ = viper.BindPFlag(flags.Key, cmd.Flag(flags.Key))
+	_ = viper.BindPFlag(flags.Label, cmd.Flag(flags.Label))
+	_ = viper.BindPFlag(flags.Type, cmd.Flag(flags.Type))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	name := viper.GetString(flags.Name)
+	namespace := viper.GetString(flags.Namespace)
+	keys := viper.GetStringSlice(flags.Key)
+	labels := viper.GetStringMapString(flags.Label)
+	secretType := viper.GetString(flags.Type)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(name) == 0 {
+		return fmt.Errorf("please input value for --name flag")
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("please input at least one --key flag")
+	}
+
+	meta, err := k8s.NewObjectMeta(name, namespace, labels)
+	if err != nil {
+		return err
+	}
+
+	refs := make([]*secretRef, 0, len(keys))
+	for _, key := range keys {
+		ref, err := parseSecretRef(key)
+		if err != nil {
+			return err
+		}
+		refs = append(refs, ref)
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	data := make(map[string][]byte)
+	for _, ref := range refs {
+		if _, ok := data[ref.key]; ok {
+			return fmt.Errorf("duplicate key %s", ref.key)
+		}
+
+		value, err := reader.read(ctx, ref.name, ref.version)
+		if err != nil {
+			return fmt.Errorf("failed to resolve key %s: %w", ref.key, err)
+		}
+
+		data[ref.key] = value.Payload
+	}
+
+	secret, err := k8s.NewSecret(meta, secretType, data)
+	if err != nil {
+		return err
+	}
+
+	return writeManifest(cmd, persistentFlags.OutputFormat, secret)
+}
@@ -3,15 +3,14 @@ package run
 import (
 	"encoding/json"
 	"fmt"
-	"path"
+	"sort"
+	"strings"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
-	"github.com/kubetrail/mksecret/pkg/app"
 	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"google.golang.org/api/iterator"
-	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 )
 
@@ -19,6 +18,20 @@ func List(cmd *cobra.Command, args []string) error {
 	ctx := cmd.Context()
 	persistentFlags := getPersistentFlags(cmd)
 
+	_ = viper.BindPFlag(flags.Tree, cmd.Flag(flags.Tree))
+
+	tree := viper.GetBool(flags.Tree)
+
+	// prefix such as team/ lists secrets within a namespace
+	prefix := ""
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+
+	if tree && persistentFlags.OutputFormat != flags.OutputFormatNative {
+		return fmt.Errorf("tree is supported only with %s output format", flags.OutputFormatNative)
+	}
+
 	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
 		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
 		return err
@@ -30,54 +43,39 @@ func List(cmd *cobra.Command, args []string) error {
 	table.SetColumnSeparator(" ")
 
 	// Create the client.
-	client, err := secretmanager.NewClient(ctx)
+	client, err := newClient(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create secret manager client: %w", err)
 	}
 	defer client.Close()
 
-	// Build the request.
-	listRequest := &secretmanagerpb.ListSecretsRequest{
-		Parent:    fmt.Sprintf("projects/%s", persistentFlags.Project),
-		PageSize:  0,
-		PageToken: "",
-		Filter:    fmt.Sprintf("labels.%s=%s", app.KeyManagedBy, app.Name),
+	secrets, err := listSecrets(ctx, client, persistentFlags.Project, "")
+	if err != nil {
+		return err
 	}
 
-	// Call the API.
-	secrets := client.ListSecrets(ctx, listRequest)
-	if err != nil {
-		return fmt.Errorf("failed to access secret version: %w", err)
+	outputList := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		name := logicalName(secret)
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		outputList = append(outputList, name)
 	}
+	sort.Strings(outputList)
 
 	switch persistentFlags.OutputFormat {
 	case flags.OutputFormatNative:
-		for {
-			secret, err := secrets.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to list secrets: %w", err)
-			}
+		if tree {
+			return namespace.Tree(cmd.OutOrStdout(), outputList)
+		}
 
-			if _, err := fmt.Fprintln(cmd.OutOrStdout(), path.Base(secret.GetName())); err != nil {
+		for _, name := range outputList {
+			if _, err := fmt.Fprintln(cmd.OutOrStdout(), name); err != nil {
 				return fmt.Errorf("failed to write to output: %w", err)
 			}
 		}
 	case flags.OutputFormatJson:
-		outputList := make([]string, 0, 128)
-		for {
-			secret, err := secrets.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to list secrets: %w", err)
-			}
-
-			outputList = append(outputList, path.Base(secret.GetName()))
-		}
 		jb, err := json.Marshal(outputList)
 		if err != nil {
 			return fmt.Errorf("failed to serialize output json: %w", err)
@@ -87,18 +85,6 @@ func List(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to write to output: %w", err)
 		}
 	case flags.OutputFormatYaml:
-		outputList := make([]string, 0, 128)
-		for {
-			secret, err := secrets.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to list secrets: %w", err)
-			}
-
-			outputList = append(outputList, path.Base(secret.GetName()))
-		}
 		jb, err := yaml.Marshal(outputList)
 		if err != nil {
 			return fmt.Errorf("failed to serialize output yaml: %w", err)
@@ -108,16 +94,8 @@ func List(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to write to output: %w", err)
 		}
 	case flags.OutputFormatTable:
-		for {
-			secret, err := secrets.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return fmt.Errorf("failed to list secrets: %w", err)
-			}
-
-			table.Append([]string{path.Base(secret.GetName())})
+		for _, name := range outputList {
+			table.Append([]string{name})
 		}
 
 		table.Render() // Send output
@@ -0,0 +1,63 @@
+package run
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kubetrail/bip32/pkg/keys"
+	"github.com/kubetrail/bip39/pkg/mnemonics"
+	"github.com/kubetrail/bip39/pkg/seeds"
+)
+
+const (
+	deriveSeed = "seed"
+	deriveXPrv = "xprv"
+)
+
+const defaultMnemonicLength = 24
+
+// newMnemonic generates a new mnemonic of requested number of words,
+// defaulting to 24 words
+func newMnemonic(length int, language string) (string, error) {
+	if length == 0 {
+		length = defaultMnemonicLength
+	}
+
+	mnemonic, err := mnemonics.New(length, language)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+
+	return mnemonic, nil
+}
+
+// tidyMnemonic normalizes white spaces of a mnemonic and validates
+// its words and checksum
+func tidyMnemonic(mnemonic, language string) (string, error) {
+	mnemonic = mnemonics.Tidy(mnemonic)
+	if err := mnemonics.Validate(mnemonic, language); err != nil {
+		return "", fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	return mnemonic, nil
+}
+
+// deriveFromMnemonic derives BIP39 seed hex or BIP32 root extended
+// private key from a mnemonic and optional BIP39 passphrase
+func deriveFromMnemonic(mnemonic, bip39Passphrase, derive string) (string, error) {
+	seed := seeds.New(mnemonic, bip39Passphrase)
+
+	switch derive {
+	case deriveSeed:
+		return hex.EncodeToString(seed), nil
+	case deriveXPrv:
+		key, err := keys.New(seed, keys.NetworkTypeMainnet, "m")
+		if err != nil {
+			return "", fmt.Errorf("failed to derive extended key: %w", err)
+		}
+		return key.XPrv, nil
+	default:
+		return "", fmt.Errorf("invalid derive option %s, valid options are %v",
+			derive, []string{deriveSeed, deriveXPrv})
+	}
+}
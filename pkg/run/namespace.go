@@ -0,0 +1,45 @@
+package run
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+// logicalName returns path-style name of a secret
+func logicalName(secret *secretmanagerpb.Secret) string {
+	return namespace.FromID(path.Base(secret.GetName()))
+}
+
+// namespaceDefaults returns defaults configured for the most specific
+// namespace of name under namespaces key of the config file
+func namespaceDefaults(name string) (namespace.Defaults, error) {
+	defaults := make(map[string]namespace.Defaults)
+	if err := viper.UnmarshalKey(flags.Namespaces, &defaults); err != nil {
+		return namespace.Defaults{}, fmt.Errorf("failed to read namespace defaults from config: %w", err)
+	}
+
+	d, _ := namespace.Lookup(defaults, name)
+	return d, nil
+}
+
+// namespaceLabels returns a copy of labels with namespace label set
+// as per name, dropping it for names without a namespace
+func namespaceLabels(labels map[string]string, name string) map[string]string {
+	out := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		out[k] = v
+	}
+	delete(out, app.KeyNamespace)
+
+	if ns, _ := namespace.Split(name); len(ns) > 0 {
+		out[app.KeyNamespace] = namespace.Label(ns)
+	}
+
+	return out
+}
@@ -0,0 +1,193 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/strength"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+// getPassphrase returns passphrase provided via --passphrase flag or
+// read from a non-interactive source such as a file, an env. var, an
+// open file descriptor or output of a command, for instance a password
+// manager. Empty passphrase is returned when none is provided.
+func getPassphrase(cmd *cobra.Command) (string, error) {
+	_ = viper.BindPFlag(flags.Passphrase, cmd.Flag(flags.Passphrase))
+	_ = viper.BindPFlag(flags.PassphraseFile, cmd.Flag(flags.PassphraseFile))
+	_ = viper.BindPFlag(flags.PassphraseEnv, cmd.Flag(flags.PassphraseEnv))
+	_ = viper.BindPFlag(flags.PassphraseFd, cmd.Flag(flags.PassphraseFd))
+	_ = viper.BindPFlag(flags.PassphraseCommand, cmd.Flag(flags.PassphraseCommand))
+
+	passphrase := viper.GetString(flags.Passphrase)
+	file := viper.GetString(flags.PassphraseFile)
+	env := viper.GetString(flags.PassphraseEnv)
+	fd := viper.GetInt(flags.PassphraseFd)
+	command := viper.GetString(flags.PassphraseCommand)
+
+	// fd zero is valid, hence fd is considered provided only when set
+	fdFlag := cmd.Flag(flags.PassphraseFd)
+	fdProvided := fdFlag != nil && fdFlag.Changed
+
+	sources := 0
+	for _, provided := range []bool{
+		len(passphrase) > 0,
+		len(file) > 0,
+		len(env) > 0,
+		fdProvided,
+		len(command) > 0,
+	} {
+		if provided {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return "", fmt.Errorf("only one of --%s, --%s, --%s, --%s or --%s can be provided",
+			flags.Passphrase, flags.PassphraseFile, flags.PassphraseEnv, flags.PassphraseFd, flags.PassphraseCommand)
+	}
+
+	switch {
+	case len(file) > 0:
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		passphrase = trimNewline(string(b))
+	case len(env) > 0:
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("passphrase env. var %s is not set", env)
+		}
+		passphrase = value
+	case fdProvided:
+		if fd < 0 {
+			return "", fmt.Errorf("invalid passphrase file descriptor %d", fd)
+		}
+
+		// stdin is read via command and is left open
+		var r io.Reader = cmd.InOrStdin()
+		if fd != 0 {
+			f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+			if f == nil {
+				return "", fmt.Errorf("invalid passphrase file descriptor %d", fd)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase from file descriptor %d: %w", fd, err)
+		}
+		passphrase = trimNewline(string(b))
+	case len(command) > 0:
+		stdout := new(bytes.Buffer)
+		c := exec.CommandContext(cmd.Context(), "sh", "-c", command)
+		c.Stdin = cmd.InOrStdin()
+		c.Stdout = stdout
+		c.Stderr = cmd.ErrOrStderr()
+		if err := c.Run(); err != nil {
+			return "", fmt.Errorf("failed to run passphrase command: %w", err)
+		}
+		passphrase = trimNewline(stdout.String())
+	}
+
+	if sources > 0 && len(passphrase) == 0 {
+		return "", fmt.Errorf("passphrase source provided an empty passphrase")
+	}
+
+	return passphrase, nil
+}
+
+// passphraseFromStdin reports whether passphrase is read from stdin
+// via file descriptor zero, in which case stdin cannot provide other
+// input such as a secret value
+func passphraseFromStdin(cmd *cobra.Command) bool {
+	f := cmd.Flag(flags.PassphraseFd)
+	if f == nil || !f.Changed {
+		return false
+	}
+
+	fd, err := strconv.Atoi(f.Value.String())
+	return err == nil && fd == 0
+}
+
+// checkPassphrase enforces passphrase policy on a passphrase used for
+// encryption. Policy is read from config and values are the plaintexts
+// passphrase protects, which it must not be the same as.
+func checkPassphrase(passphrase string, values ...[]byte) error {
+	policy := &strength.Policy{
+		MinLength:  strength.DefaultMinLength,
+		MinEntropy: strength.DefaultMinEntropy,
+	}
+
+	if viper.IsSet(flags.PassphraseMinLength) {
+		policy.MinLength = viper.GetInt(flags.PassphraseMinLength)
+	}
+
+	if viper.IsSet(flags.PassphraseMinEntropy) {
+		policy.MinEntropy = viper.GetFloat64(flags.PassphraseMinEntropy)
+	}
+
+	if file := viper.GetString(flags.PassphraseBannedWords); len(file) > 0 {
+		words, err := strength.ReadWords(file)
+		if err != nil {
+			return fmt.Errorf("failed to read banned words: %w", err)
+		}
+		policy.BannedWords = words
+	}
+
+	return policy.Check(passphrase, values...)
+}
+
+// isNewPassphrase reports whether passphrase does not decrypt the latest
+// enabled version of a secret, i.e., whether secret is new or is being
+// rekeyed. Policy is enforced on new passphrases only, so that secrets
+// encrypted before the policy was in place can still be updated.
+func isNewPassphrase(
+	ctx context.Context,
+	client *secretmanager.Client,
+	secret *secretmanagerpb.Secret,
+	passphrase string,
+) (bool, error) {
+	versions, err := listVersions(ctx, client, secret.GetName(), "state:ENABLED")
+	if err != nil {
+		return false, err
+	}
+
+	if len(versions) == 0 {
+		return true, nil
+	}
+
+	payload, err := accessVersion(ctx, client, versions[len(versions)-1].GetName())
+	if err != nil {
+		return false, err
+	}
+
+	ciphertext, err := base58.Decode(string(payload))
+	if err != nil {
+		return true, nil
+	}
+
+	_, err = decryptWithPassphrase(ciphertext, passphrase)
+	return err != nil, nil
+}
+
+// trimNewline removes a single trailing newline so that passphrases
+// may otherwise begin or end with white spaces
+func trimNewline(input string) string {
+	input = strings.TrimSuffix(input, "\n")
+	return strings.TrimSuffix(input, "\r")
+}
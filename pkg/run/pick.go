@@ -0,0 +1,146 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/picker"
+	"github.com/spf13/cobra"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+// secretDetail summarizes labels and last updated time of a secret
+// leaving out labels common to all managed secrets and the namespace
+// label, which is already part of the name. Secret is last updated
+// when its latest version was added, or when it was created if it has
+// no versions.
+func secretDetail(
+	ctx context.Context,
+	client *secretmanager.Client,
+	secret *secretmanagerpb.Secret,
+) (string, error) {
+	labels := make([]string, 0, len(secret.GetLabels()))
+	for k, v := range secret.GetLabels() {
+		if k == app.KeyManagedBy || k == app.KeyNamespace {
+			continue
+		}
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(labels)
+
+	versions, err := listVersions(ctx, client, secret.GetName(), "")
+	if err != nil {
+		return "", err
+	}
+
+	updated := secret.GetCreateTime()
+	if len(versions) > 0 {
+		updated = versions[len(versions)-1].GetCreateTime()
+	}
+
+	detail := strings.Join(labels, ",")
+	if updated != nil {
+		detail = strings.TrimSpace(fmt.Sprintf("%s updated %s", detail, updated.AsTime().Local().Format("2006-01-02 15:04")))
+	}
+
+	return detail, nil
+}
+
+// secretName returns name of the secret from args, if provided, else
+// lets user pick one from managed secrets when input is a terminal
+func secretName(
+	ctx context.Context,
+	cmd *cobra.Command,
+	client *secretmanager.Client,
+	project string,
+	args []string,
+) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+
+	if !picker.IsTerminal(cmd.InOrStdin()) {
+		return "", fmt.Errorf("please provide name of the secret")
+	}
+
+	secrets, err := listSecrets(ctx, client, project, "")
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].GetName() < secrets[j].GetName()
+	})
+
+	items := make([]picker.Item, 0, len(secrets))
+	for _, secret := range secrets {
+		detail, err := secretDetail(ctx, client, secret)
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, picker.Item{
+			Name:   logicalName(secret),
+			Detail: detail,
+		})
+	}
+
+	name, err := picker.Pick(cmd.InOrStdin().(*os.File), cmd.ErrOrStderr(), "Secret", items)
+	if err != nil {
+		return "", fmt.Errorf("failed to pick secret: %w", err)
+	}
+
+	return name, nil
+}
+
+// SecretNames lists names of managed secrets starting with prefix
+// along with their details for shell completion
+func SecretNames(cmd *cobra.Command, prefix string) ([]string, error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	persistentFlags := getPersistentFlags(cmd)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		return nil, fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secrets, err := listSecrets(ctx, client, persistentFlags.Project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		name := logicalName(secret)
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		detail, err := secretDetail(ctx, client, secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(detail) > 0 {
+			name = fmt.Sprintf("%s\t%s", name, detail)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
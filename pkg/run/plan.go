@@ -0,0 +1,679 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/manifest"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/kubetrail/mksecret/pkg/prompter"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	planActionCreate   = "create"
+	planActionUpdate   = "update"
+	planActionDelete   = "delete"
+	planActionConflict = "conflict"
+)
+
+// planActionSymbols prefix plan changes in native output
+var planActionSymbols = map[string]string{
+	planActionCreate:   "+",
+	planActionUpdate:   "~",
+	planActionDelete:   "-",
+	planActionConflict: "!",
+}
+
+// planChange is a change required to reconcile a secret
+// with its declaration
+type planChange struct {
+	Action  string           `json:"action" yaml:"action"`
+	Name    string           `json:"name" yaml:"name"`
+	Fields  []string         `json:"fields,omitempty" yaml:"fields,omitempty"`
+	Reason  string           `json:"reason,omitempty" yaml:"reason,omitempty"`
+	desired *manifest.Secret `json:"-" yaml:"-"`
+
+	// existing is the secret being updated
+	existing *secretmanagerpb.Secret
+}
+
+// keepAppLabels copies labels owned by this app from an existing
+// secret so that a labels update does not remove them
+func keepAppLabels(want, got *secretmanagerpb.Secret) {
+	for k, v := range got.GetLabels() {
+		if _, ok := want.Labels[k]; !ok && app.IsReservedLabel(k) {
+			want.Labels[k] = v
+		}
+	}
+}
+
+// desiredSecret converts a declaration to secret manager secret
+func desiredSecret(project string, s *manifest.Secret) *secretmanagerpb.Secret {
+	labels := map[string]string{
+		app.KeyManagedBy: app.Name,
+	}
+	for k, v := range s.Labels {
+		labels[k] = v
+	}
+	if s.Encrypted {
+		labels[app.KeyEncrypted] = app.ValueTrue
+	}
+
+	// names are validated when the manifest is loaded
+	id, _ := namespace.ToID(s.Name)
+
+	secret := &secretmanagerpb.Secret{
+		Name:   fmt.Sprintf("projects/%s/secrets/%s", project, id),
+		Labels: namespaceLabels(labels, s.Name),
+	}
+
+	if s.Replication != nil && len(s.Replication.Locations) > 0 {
+		replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(s.Replication.Locations))
+		for _, location := range s.Replication.Locations {
+			replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+		}
+		secret.Replication = &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_UserManaged_{
+				UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+			},
+		}
+	} else {
+		secret.Replication = &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: &secretmanagerpb.Replication_Automatic{},
+			},
+		}
+	}
+
+	// values are validated when the manifest is loaded
+	if ttl, _ := s.TtlDuration(); ttl > 0 {
+		secret.Expiration = &secretmanagerpb.Secret_Ttl{Ttl: durationpb.New(ttl)}
+	}
+	if expireTime, _ := s.ExpireTimestamp(); !expireTime.IsZero() {
+		secret.Expiration = &secretmanagerpb.Secret_ExpireTime{ExpireTime: timestamppb.New(expireTime)}
+	}
+
+	if s.Rotation != nil {
+		secret.Rotation = &secretmanagerpb.Rotation{}
+		if period, _ := s.Rotation.PeriodDuration(); period > 0 {
+			secret.Rotation.RotationPeriod = durationpb.New(period)
+		}
+		if next, _ := s.Rotation.NextRotationTimestamp(); !next.IsZero() {
+			secret.Rotation.NextRotationTime = timestamppb.New(next)
+		}
+	}
+
+	for _, topic := range s.Topics {
+		secret.Topics = append(secret.Topics, &secretmanagerpb.Topic{Name: topic})
+	}
+
+	return secret
+}
+
+// replicationLocations returns sorted user managed replica locations
+// or nil for automatic replication
+func replicationLocations(r *secretmanagerpb.Replication) []string {
+	var locations []string
+	for _, replica := range r.GetUserManaged().GetReplicas() {
+		locations = append(locations, replica.GetLocation())
+	}
+	sort.Strings(locations)
+
+	return locations
+}
+
+// topicNames returns sorted topic names
+func topicNames(topics []*secretmanagerpb.Topic) []string {
+	var names []string
+	for _, topic := range topics {
+		names = append(names, topic.GetName())
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// diffSecret compares a declaration with an existing secret returning
+// update mask paths that need to change or a conflict reason when
+// an immutable property differs
+func diffSecret(desired *manifest.Secret, want, got *secretmanagerpb.Secret) ([]string, string) {
+	encrypted := got.GetLabels()[app.KeyEncrypted] == app.ValueTrue
+	if encrypted != desired.Encrypted {
+		return nil, "encryption is immutable"
+	}
+
+	if strings.Join(replicationLocations(want.GetReplication()), ",") !=
+		strings.Join(replicationLocations(got.GetReplication()), ",") {
+		return nil, "replication is immutable"
+	}
+
+	var paths []string
+
+	gotLabels := make(map[string]string)
+	for k, v := range got.GetLabels() {
+		if !app.IsReservedLabel(k) {
+			gotLabels[k] = v
+		}
+	}
+	if len(gotLabels) != len(desired.Labels) {
+		paths = append(paths, "labels")
+	} else {
+		for k, v := range desired.Labels {
+			if value, ok := gotLabels[k]; !ok || value != v {
+				paths = append(paths, "labels")
+				break
+			}
+		}
+	}
+
+	// ttl is converted to an expire time by the service, so a declared
+	// ttl is only applied when the secret has no expiration
+	switch {
+	case want.GetTtl() != nil:
+		if got.GetExpireTime() == nil {
+			paths = append(paths, "ttl")
+		}
+	case want.GetExpireTime() != nil:
+		if !want.GetExpireTime().AsTime().Equal(got.GetExpireTime().AsTime()) || got.GetExpireTime() == nil {
+			paths = append(paths, "expire_time")
+		}
+	default:
+		if got.GetExpireTime() != nil {
+			paths = append(paths, "expire_time")
+		}
+	}
+
+	switch {
+	case want.GetRotation() == nil && got.GetRotation() != nil,
+		want.GetRotation() != nil && got.GetRotation() == nil:
+		paths = append(paths, "rotation")
+	case want.GetRotation() != nil:
+		if want.GetRotation().GetRotationPeriod().AsDuration() != got.GetRotation().GetRotationPeriod().AsDuration() {
+			paths = append(paths, "rotation")
+		} else if next := want.GetRotation().GetNextRotationTime(); next != nil &&
+			!next.AsTime().Equal(got.GetRotation().GetNextRotationTime().AsTime()) {
+			paths = append(paths, "rotation")
+		}
+	}
+
+	if strings.Join(topicNames(want.GetTopics()), ",") != strings.Join(topicNames(got.GetTopics()), ",") {
+		paths = append(paths, "topics")
+	}
+
+	return paths, ""
+}
+
+// computePlan computes changes required to reconcile existing
+// secrets with the manifest. Secrets not declared in the manifest
+// are deleted only when pruning.
+func computePlan(
+	project string,
+	m *manifest.Manifest,
+	existing []*secretmanagerpb.Secret,
+	prune bool,
+) []planChange {
+	existingByName := make(map[string]*secretmanagerpb.Secret)
+	for _, secret := range existing {
+		existingByName[logicalName(secret)] = secret
+	}
+
+	changes := make([]planChange, 0, len(m.Secrets))
+	declared := make(map[string]struct{})
+
+	for i := range m.Secrets {
+		desired := &m.Secrets[i]
+		declared[desired.Name] = struct{}{}
+
+		got, ok := existingByName[desired.Name]
+		if !ok {
+			change := planChange{
+				Action:  planActionCreate,
+				Name:    desired.Name,
+				desired: desired,
+			}
+			if desired.Value != nil {
+				change.Fields = []string{"value"}
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		paths, reason := diffSecret(desired, desiredSecret(project, desired), got)
+		if len(reason) > 0 {
+			changes = append(
+				changes,
+				planChange{
+					Action:  planActionConflict,
+					Name:    desired.Name,
+					Reason:  reason,
+					desired: desired,
+				},
+			)
+			continue
+		}
+
+		if desired.Value != nil {
+			paths = append(paths, "value")
+		}
+
+		if len(paths) > 0 {
+			changes = append(
+				changes,
+				planChange{
+					Action:   planActionUpdate,
+					Name:     desired.Name,
+					Fields:   paths,
+					desired:  desired,
+					existing: got,
+				},
+			)
+		}
+	}
+
+	if prune {
+		names := make([]string, 0, len(existingByName))
+		for name := range existingByName {
+			if _, ok := declared[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			changes = append(
+				changes,
+				planChange{
+					Action:   planActionDelete,
+					Name:     name,
+					existing: existingByName[name],
+				},
+			)
+		}
+	}
+
+	return changes
+}
+
+// writePlan writes plan changes in requested output format
+func writePlan(w io.Writer, outputFormat string, changes []planChange) error {
+	switch outputFormat {
+	case flags.OutputFormatNative:
+		if len(changes) == 0 {
+			if _, err := fmt.Fprintln(w, "No changes"); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+		for _, change := range changes {
+			line := fmt.Sprintf("%s %s %s", planActionSymbols[change.Action], change.Action, change.Name)
+			if len(change.Fields) > 0 {
+				line = fmt.Sprintf("%s: %s", line, strings.Join(change.Fields, ", "))
+			}
+			if len(change.Reason) > 0 {
+				line = fmt.Sprintf("%s: %s", line, change.Reason)
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(w, string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(w, string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Action", "Name", "Fields", "Reason"})
+		for _, change := range changes {
+			table.Append(
+				[]string{
+					change.Action,
+					change.Name,
+					strings.Join(change.Fields, ","),
+					change.Reason,
+				},
+			)
+		}
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
+
+// loadPlan loads the manifest and computes the plan against
+// existing secrets
+func loadPlan(
+	ctx context.Context,
+	client *secretmanager.Client,
+	project, filename string,
+	prune bool,
+) ([]planChange, error) {
+	if len(filename) == 0 {
+		return nil, fmt.Errorf("please input value for --file flag")
+	}
+
+	m, err := manifest.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := listSecrets(ctx, client, project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return computePlan(project, m, existing, prune), nil
+}
+
+// confirmPrune lists secrets that will be deleted and asks for
+// confirmation, which cannot be given when prompts are hidden
+func confirmPrune(cmd *cobra.Command, prompt prompter.Prompter, noPrompt bool, changes []planChange) error {
+	var names []string
+	for _, change := range changes {
+		if change.Action == planActionDelete {
+			names = append(names, change.Name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	if noPrompt {
+		return fmt.Errorf("please use --%s to prune %d secrets without confirmation", flags.Force, len(names))
+	}
+
+	w := cmd.ErrOrStderr()
+	if _, err := fmt.Fprintln(w, "Following secrets will be deleted:"); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "  %s\n", name); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	}
+
+	input, err := prompt.Line(fmt.Sprintf("Type %s to delete %d secrets", flags.Prune, len(names)))
+	if err != nil {
+		return err
+	}
+
+	if input != flags.Prune {
+		return fmt.Errorf("input does not match %s", flags.Prune)
+	}
+
+	return nil
+}
+
+func Plan(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.File, cmd.Flag(flags.File))
+	_ = viper.BindPFlag(flags.Prune, cmd.Flag(flags.Prune))
+
+	filename := viper.GetString(flags.File)
+	prune := viper.GetBool(flags.Prune)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	changes, err := loadPlan(ctx, client, persistentFlags.Project, filename, prune)
+	if err != nil {
+		return err
+	}
+
+	return writePlan(cmd.OutOrStdout(), persistentFlags.OutputFormat, changes)
+}
+
+func Apply(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.File, cmd.Flag(flags.File))
+	_ = viper.BindPFlag(flags.Prune, cmd.Flag(flags.Prune))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+	_ = viper.BindPFlag(flags.Force, cmd.Flag(flags.Force))
+
+	filename := viper.GetString(flags.File)
+	prune := viper.GetBool(flags.Prune)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+	force := viper.GetBool(flags.Force)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	changes, err := loadPlan(ctx, client, persistentFlags.Project, filename, prune)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.Action == planActionConflict {
+			return fmt.Errorf("cannot apply, secret %s has a conflict: %s", change.Name, change.Reason)
+		}
+	}
+
+	if !force {
+		if err := confirmPrune(cmd, prompt, noPrompt, changes); err != nil {
+			return err
+		}
+	}
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	writer := &secretWriter{
+		client:  client,
+		project: persistentFlags.Project,
+	}
+
+	// encryptionKey lazily derives the key from the passphrase
+	// prompting for a new one if not provided. Passphrase is checked
+	// against each value it encrypts when it is new for the secret.
+	var key []byte
+	var keyPassphrase string
+	encryptionKey := func(secret *secretmanagerpb.Secret, value []byte) ([]byte, error) {
+		if len(reader.passphrase) == 0 {
+			reader.passphrase, err = promptNewPassphrase(prompt)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		newPassphrase, err := isNewPassphrase(ctx, client, secret, reader.passphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		if newPassphrase {
+			if err := checkPassphrase(reader.passphrase, value); err != nil {
+				return nil, err
+			}
+		}
+
+		if key != nil && keyPassphrase == reader.passphrase {
+			return key, nil
+		}
+
+		key, err = crypto.NewAesKeyFromPassphrase([]byte(reader.passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate new AES key: %w", err)
+		}
+		keyPassphrase = reader.passphrase
+
+		return key, nil
+	}
+
+	applied := make([]planChange, 0, len(changes))
+	for _, change := range changes {
+		switch change.Action {
+		case planActionCreate:
+			want := desiredSecret(persistentFlags.Project, change.desired)
+			id := path.Base(want.GetName())
+			want.Name = ""
+			secret, err := client.CreateSecret(
+				ctx,
+				&secretmanagerpb.CreateSecretRequest{
+					Parent:   fmt.Sprintf("projects/%s", persistentFlags.Project),
+					SecretId: id,
+					Secret:   want,
+				},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to create secret %s: %w", change.Name, err)
+			}
+
+			if change.desired.Value != nil {
+				value, err := change.desired.Value.Read()
+				if err != nil {
+					return fmt.Errorf("secret %s: %w", change.Name, err)
+				}
+
+				var valueKey []byte
+				if change.desired.Encrypted {
+					if valueKey, err = encryptionKey(secret, value); err != nil {
+						return err
+					}
+				}
+
+				if _, err := writer.add(ctx, secret, value, valueKey); err != nil {
+					return fmt.Errorf("secret %s: %w", change.Name, err)
+				}
+			}
+		case planActionUpdate:
+			want := desiredSecret(persistentFlags.Project, change.desired)
+			keepAppLabels(want, change.existing)
+
+			var paths []string
+			for _, field := range change.Fields {
+				if field != "value" {
+					paths = append(paths, field)
+				}
+			}
+
+			secret := want
+			if len(paths) > 0 {
+				secret, err = client.UpdateSecret(
+					ctx,
+					&secretmanagerpb.UpdateSecretRequest{
+						Secret:     want,
+						UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+					},
+				)
+				if err != nil {
+					return fmt.Errorf("failed to update secret %s: %w", change.Name, err)
+				}
+			}
+
+			if change.desired.Value != nil {
+				value, err := change.desired.Value.Read()
+				if err != nil {
+					return fmt.Errorf("secret %s: %w", change.Name, err)
+				}
+
+				// latest value is compared so that a version is only
+				// added when the value has changed
+				latest, err := reader.read(ctx, change.Name, "latest")
+				if err != nil && !isNotFound(err) {
+					return fmt.Errorf("failed to compare value of secret %s: %w", change.Name, err)
+				}
+
+				if latest != nil && bytes.Equal(latest.Payload, value) {
+					if len(paths) == 0 {
+						continue
+					}
+					change.Fields = paths
+				} else {
+					var valueKey []byte
+					if change.desired.Encrypted {
+						if valueKey, err = encryptionKey(secret, value); err != nil {
+							return err
+						}
+					}
+
+					if _, err := writer.add(ctx, secret, value, valueKey); err != nil {
+						return fmt.Errorf("secret %s: %w", change.Name, err)
+					}
+				}
+			}
+		case planActionDelete:
+			if err := client.DeleteSecret(
+				ctx,
+				&secretmanagerpb.DeleteSecretRequest{
+					Name: change.existing.GetName(),
+				},
+			); err != nil {
+				return fmt.Errorf("failed to delete secret %s: %w", change.Name, err)
+			}
+		}
+
+		applied = append(applied, change)
+	}
+
+	return writePlan(cmd.OutOrStdout(), persistentFlags.OutputFormat, applied)
+}
@@ -0,0 +1,79 @@
+package run
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/manifest"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+func TestComputePlanNamespaces(t *testing.T) {
+	existingSecret := func(id string, labels map[string]string) *secretmanagerpb.Secret {
+		l := map[string]string{app.KeyManagedBy: app.Name}
+		for k, v := range labels {
+			l[k] = v
+		}
+
+		return &secretmanagerpb.Secret{
+			Name:   "projects/p/secrets/" + id,
+			Labels: l,
+			Replication: &secretmanagerpb.Replication{
+				Replication: &secretmanagerpb.Replication_Automatic_{
+					Automatic: &secretmanagerpb.Replication_Automatic{},
+				},
+			},
+		}
+	}
+
+	existing := []*secretmanagerpb.Secret{
+		existingSecret("team_app_db-password", map[string]string{app.KeyNamespace: "team_app", "env": "dev"}),
+		existingSecret("team_old", map[string]string{app.KeyNamespace: "team"}),
+		existingSecret("api-key", nil),
+	}
+
+	m := &manifest.Manifest{
+		Secrets: []manifest.Secret{
+			{Name: "team/app/db-password", Labels: map[string]string{"env": "prod"}},
+			{Name: "team/new-secret"},
+			{Name: "api-key"},
+		},
+	}
+
+	changes := computePlan("p", m, existing, true)
+
+	type change struct {
+		action string
+		name   string
+		fields []string
+	}
+
+	var got []change
+	for _, c := range changes {
+		got = append(got, change{action: c.Action, name: c.Name, fields: c.Fields})
+	}
+
+	want := []change{
+		{action: planActionUpdate, name: "team/app/db-password", fields: []string{"labels"}},
+		{action: planActionCreate, name: "team/new-secret"},
+		{action: planActionDelete, name: "team/old"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("computePlan() = %+v, want %+v", got, want)
+	}
+
+	if name := changes[2].existing.GetName(); name != "projects/p/secrets/team_old" {
+		t.Errorf("delete change refers to %s", name)
+	}
+
+	secret := desiredSecret("p", &m.Secrets[1])
+	if secret.GetName() != "projects/p/secrets/team_new-secret" {
+		t.Errorf("desiredSecret() name = %s", secret.GetName())
+	}
+
+	if secret.GetLabels()[app.KeyNamespace] != "team" {
+		t.Errorf("desiredSecret() labels = %v, want namespace label", secret.GetLabels())
+	}
+}
@@ -0,0 +1,63 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/kubetrail/mksecret/pkg/prompter"
+	"github.com/spf13/cobra"
+)
+
+// newPrompter returns prompter carried by command context, if any, else
+// a prompter reading from command input and writing prompts to command
+// error output. Prompts are hidden when input is piped or noPrompt is set.
+func newPrompter(cmd *cobra.Command, noPrompt bool) (prompter.Prompter, error) {
+	if p, ok := prompter.FromContext(cmd.Context()); ok {
+		return p, nil
+	}
+
+	show, err := prompter.Interactive(cmd.InOrStdin())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt status: %w", err)
+	}
+
+	if noPrompt {
+		show = false
+	}
+
+	return prompter.New(cmd.InOrStdin(), cmd.ErrOrStderr(), show), nil
+}
+
+// promptNewPassphrase reads a new encryption passphrase twice
+// and ensures both entries match
+func promptNewPassphrase(p prompter.Prompter) (string, error) {
+	if err := p.Print("This input will be encrypted using your password"); err != nil {
+		return "", err
+	}
+
+	encryptionKey, err := p.Password("Enter encryption password (min 8 char)")
+	if err != nil {
+		return "", fmt.Errorf("failed to read encryption password: %w", err)
+	}
+
+	encryptionKeyConfirm, err := p.Password("Enter encryption password again")
+	if err != nil {
+		return "", fmt.Errorf("failed to read encryption password: %w", err)
+	}
+
+	if !bytes.Equal(encryptionKey, encryptionKeyConfirm) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+
+	return string(encryptionKey), nil
+}
+
+// promptPassphrase reads an existing passphrase
+func promptPassphrase(p prompter.Prompter) (string, error) {
+	passphrase, err := p.Password("Enter secret passphrase")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	return string(passphrase), nil
+}
@@ -0,0 +1,184 @@
+package run
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/k8s"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func ProviderManifest(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Kind, cmd.Flag(flags.Kind))
+	_ = viper.BindPFlag(flags.Name, cmd.Flag(flags.Name))
+	_ = viper.BindPFlag(flags.Namespace, cmd.Flag(flags.Namespace))
+	_ = viper.BindPFlag(flags.Key, cmd.Flag(flags.Key))
+	_ = viper.BindPFlag(flags.Selector, cmd.Flag(flags.Selector))
+	_ = viper.BindPFlag(flags.Label, cmd.Flag(flags.Label))
+	_ = viper.BindPFlag(flags.SecretStore, cmd.Flag(flags.SecretStore))
+	_ = viper.BindPFlag(flags.SecretStoreKind, cmd.Flag(flags.SecretStoreKind))
+	_ = viper.BindPFlag(flags.RefreshInterval, cmd.Flag(flags.RefreshInterval))
+
+	kind := viper.GetString(flags.Kind)
+	name := viper.GetString(flags.Name)
+	namespace := viper.GetString(flags.Namespace)
+	keys := viper.GetStringSlice(flags.Key)
+	selector := viper.GetString(flags.Selector)
+	labels := viper.GetStringMapString(flags.Label)
+	secretStore := viper.GetString(flags.SecretStore)
+	secretStoreKind := viper.GetString(flags.SecretStoreKind)
+	refreshInterval := viper.GetString(flags.RefreshInterval)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	switch kind {
+	case k8s.KindExternalSecret, k8s.KindSecretProviderClass:
+	default:
+		return fmt.Errorf("invalid kind %s, valid kinds are %v",
+			kind,
+			[]string{k8s.KindExternalSecret, k8s.KindSecretProviderClass},
+		)
+	}
+
+	if len(name) == 0 {
+		return fmt.Errorf("please input value for --name flag")
+	}
+
+	if len(keys) == 0 && len(selector) == 0 {
+		return fmt.Errorf("please input --key or --selector flags")
+	}
+
+	meta, err := k8s.NewObjectMeta(name, namespace, labels)
+	if err != nil {
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	refs := make([]k8s.SecretReference, 0, len(keys))
+	// output keys and names of referenced secrets are tracked separately
+	// since a key may be named after an unrelated secret
+	outputKeys := make(map[string]struct{})
+	referenced := make(map[string]struct{})
+	encrypted := make([]string, 0, len(keys))
+
+	// only secret metadata is fetched, payloads are never accessed
+	for _, key := range keys {
+		ref, err := parseSecretRef(key)
+		if err != nil {
+			return err
+		}
+
+		if _, ok := outputKeys[ref.key]; ok {
+			return fmt.Errorf("duplicate key %s", ref.key)
+		}
+		outputKeys[ref.key] = struct{}{}
+
+		secret, err := getManagedSecret(ctx, client, persistentFlags.Project, ref.name)
+		if err != nil {
+			return err
+		}
+
+		referenced[secret.GetName()] = struct{}{}
+
+		if secret.GetLabels()[app.KeyEncrypted] == app.ValueTrue {
+			encrypted = append(encrypted, ref.name)
+		}
+
+		refs = append(
+			refs,
+			k8s.SecretReference{
+				Key:     ref.key,
+				Project: persistentFlags.Project,
+				Name:    path.Base(secret.GetName()),
+				Version: ref.version,
+			},
+		)
+	}
+
+	if len(selector) > 0 {
+		secrets, err := listSecrets(ctx, client, persistentFlags.Project, selector)
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(secrets, func(i, j int) bool {
+			return secrets[i].GetName() < secrets[j].GetName()
+		})
+
+		for _, secret := range secrets {
+			// secrets referenced via keys are not repeated
+			if _, ok := referenced[secret.GetName()]; ok {
+				continue
+			}
+			referenced[secret.GetName()] = struct{}{}
+
+			secretName := path.Base(secret.GetName())
+			if _, ok := outputKeys[secretName]; ok {
+				return fmt.Errorf("selected secret %s collides with key %s, please reference it via --key with another key", logicalName(secret), secretName)
+			}
+			outputKeys[secretName] = struct{}{}
+
+			if secret.GetLabels()[app.KeyEncrypted] == app.ValueTrue {
+				encrypted = append(encrypted, secretName)
+			}
+
+			refs = append(
+				refs,
+				k8s.SecretReference{
+					Key:     secretName,
+					Project: persistentFlags.Project,
+					Name:    secretName,
+					Version: "latest",
+				},
+			)
+		}
+	}
+
+	if len(encrypted) > 0 {
+		if _, err := fmt.Fprintf(
+			cmd.ErrOrStderr(),
+			"Warning: secrets %v are encrypted by %s and will be synced as ciphertext\n",
+			encrypted,
+			app.Name,
+		); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	}
+
+	var manifest interface{}
+	switch kind {
+	case k8s.KindExternalSecret:
+		manifest, err = k8s.NewExternalSecret(
+			meta,
+			k8s.SecretStoreRef{
+				Name: secretStore,
+				Kind: secretStoreKind,
+			},
+			refreshInterval,
+			refs,
+		)
+	case k8s.KindSecretProviderClass:
+		manifest, err = k8s.NewSecretProviderClass(meta, refs)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeManifest(cmd, persistentFlags.OutputFormat, manifest)
+}
@@ -0,0 +1,164 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+// copyVersion copies a version of a secret to the destination secret
+// preserving its state. Disabled versions are enabled temporarily to
+// access their payload and copies are verified by reading them back.
+func copyVersion(
+	ctx context.Context,
+	client *secretmanager.Client,
+	version *secretmanagerpb.SecretVersion,
+	dstSecretName string,
+) error {
+	disabled := version.GetState() == secretmanagerpb.SecretVersion_DISABLED
+
+	if disabled {
+		if _, err := client.EnableSecretVersion(
+			ctx,
+			&secretmanagerpb.EnableSecretVersionRequest{
+				Name: version.GetName(),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to enable secret version: %w", err)
+		}
+	}
+
+	payload, err := accessVersion(ctx, client, version.GetName())
+
+	if disabled {
+		if _, err := client.DisableSecretVersion(
+			ctx,
+			&secretmanagerpb.DisableSecretVersionRequest{
+				Name: version.GetName(),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	copied, err := addStoredVersion(ctx, client, dstSecretName, payload, false)
+	if err != nil {
+		return err
+	}
+
+	stored, err := accessVersion(ctx, client, copied.GetName())
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(payload, stored) {
+		return fmt.Errorf("checksum mismatch for copy of %s", version.GetName())
+	}
+
+	if disabled {
+		if _, err := client.DisableSecretVersion(
+			ctx,
+			&secretmanagerpb.DisableSecretVersionRequest{
+				Name: copied.GetName(),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func Rename(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Force, cmd.Flags().Lookup(filepath.Base(flags.Force)))
+
+	name := args[0]
+	newName := args[1]
+	force := viper.GetBool(flags.Force)
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if err := namespace.Validate(newName); err != nil {
+		return fmt.Errorf("invalid new name: %w", err)
+	}
+
+	if name == newName {
+		return fmt.Errorf("new name is same as the old name")
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secret, err := getManagedSecret(ctx, client, persistentFlags.Project, name)
+	if err != nil {
+		return err
+	}
+
+	// destroyed versions have no payload and are not copied
+	versions, err := listVersions(ctx, client, secret.GetName(), "NOT state:DESTROYED")
+	if err != nil {
+		return err
+	}
+
+	newSecret, err := createSecretFrom(ctx, client, persistentFlags.Project, newName, secret)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if err := copyVersion(ctx, client, version, newSecret.GetName()); err != nil {
+			return fmt.Errorf("failed to copy version %s, %s is left partially copied: %w",
+				path.Base(version.GetName()), newName, err)
+		}
+	}
+
+	if !force {
+		prompt, err := newPrompter(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		input, err := prompt.Line(fmt.Sprintf("Copied %d versions to %s. Type secret name to delete", len(versions), newName))
+		if err != nil {
+			return err
+		}
+
+		if input != name {
+			return fmt.Errorf("input does not match secret name, %s was not deleted", name)
+		}
+	}
+
+	if err := client.DeleteSecret(
+		ctx,
+		&secretmanagerpb.DeleteSecretRequest{
+			Name: secret.GetName(),
+		},
+	); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	return nil
+}
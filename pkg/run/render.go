@@ -0,0 +1,190 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// templateFuncs returns template functions that fetch secrets
+// using the reader. Fetched values are cached so that each secret
+// version is accessed only once per rendering.
+func templateFuncs(ctx context.Context, reader *secretReader) template.FuncMap {
+	cache := make(map[string][]byte)
+
+	fetch := func(name, version string) ([]byte, error) {
+		key := fmt.Sprintf("%s@%s", name, version)
+		if payload, ok := cache[key]; ok {
+			return payload, nil
+		}
+
+		value, err := reader.read(ctx, name, version)
+		if err != nil {
+			return nil, err
+		}
+
+		cache[key] = value.Payload
+		return value.Payload, nil
+	}
+
+	return template.FuncMap{
+		"secret": func(name string) (string, error) {
+			payload, err := fetch(name, "latest")
+			if err != nil {
+				return "", err
+			}
+			return string(payload), nil
+		},
+		"secretVersion": func(name, version string) (string, error) {
+			payload, err := fetch(name, version)
+			if err != nil {
+				return "", err
+			}
+			return string(payload), nil
+		},
+		"field": func(name, key string) (string, error) {
+			payload, err := fetch(name, "latest")
+			if err != nil {
+				return "", err
+			}
+
+			fields := make(map[string]interface{})
+			if err := json.Unmarshal(payload, &fields); err != nil {
+				return "", fmt.Errorf("secret %s is not a json object: %w", name, err)
+			}
+
+			value, ok := fields[key]
+			if !ok {
+				return "", fmt.Errorf("field %s not found in secret %s", key, name)
+			}
+
+			if s, ok := value.(string); ok {
+				return s, nil
+			}
+
+			jb, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("failed to serialize field %s of secret %s: %w", key, name, err)
+			}
+
+			return string(jb), nil
+		},
+	}
+}
+
+// writeFilePrivate writes data to a file with 0600 permissions. Data is
+// first written to a temp file in the same dir, which is then renamed,
+// so that an existing file is never left partially written or with
+// broader permissions.
+func writeFilePrivate(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0600); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write to file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), filename); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	return nil
+}
+
+func Render(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.File, cmd.Flag(flags.File))
+	_ = viper.BindPFlag(flags.Out, cmd.Flag(flags.Out))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	filename := viper.GetString(flags.File)
+	out := viper.GetString(flags.Out)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(filename) == 0 {
+		return fmt.Errorf("please input value for --file flag")
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read template file: %w", err)
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	tmpl, err := template.New(filepath.Base(filename)).
+		Option("missingkey=error").
+		Funcs(templateFuncs(ctx, reader)).
+		Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	bb := new(bytes.Buffer)
+	if err := tmpl.Execute(bb, nil); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	if len(out) == 0 {
+		if _, err := cmd.OutOrStdout().Write(bb.Bytes()); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+		return nil
+	}
+
+	if err := writeFilePrivate(out, bb.Bytes()); err != nil {
+		return fmt.Errorf("failed to write rendered output: %w", err)
+	}
+
+	return nil
+}
@@ -1,58 +1,97 @@
 package run
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
-	"github.com/googleapis/gax-go/v2/apierror"
-	"github.com/kubetrail/bip32/pkg/keys"
-	"github.com/kubetrail/bip39/pkg/prompts"
+	"github.com/kubetrail/mksecret/pkg/agent"
 	"github.com/kubetrail/mksecret/pkg/app"
 	"github.com/kubetrail/mksecret/pkg/crypto"
 	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/generate"
+	"github.com/kubetrail/mksecret/pkg/namespace"
 	"github.com/mr-tron/base58"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"golang.org/x/term"
 	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
-	"google.golang.org/grpc/codes"
 	"gopkg.in/yaml.v3"
-	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func Set(cmd *cobra.Command, args []string) error {
+	_ = viper.BindPFlag(flags.Generate, cmd.Flag(flags.Generate))
+	return setSecret(cmd, args, viper.GetBool(flags.Generate))
+}
+
+func Generate(cmd *cobra.Command, args []string) error {
+	return setSecret(cmd, args, true)
+}
+
+// generatePolicy reads value generation policy from flags
+func generatePolicy(cmd *cobra.Command) *generate.Policy {
+	_ = viper.BindPFlag(flags.Kind, cmd.Flag(flags.Kind))
+	_ = viper.BindPFlag(flags.Length, cmd.Flag(flags.Length))
+	_ = viper.BindPFlag(flags.Charset, cmd.Flag(flags.Charset))
+	_ = viper.BindPFlag(flags.ExcludeAmbiguous, cmd.Flag(flags.ExcludeAmbiguous))
+	_ = viper.BindPFlag(flags.Words, cmd.Flag(flags.Words))
+	_ = viper.BindPFlag(flags.Separator, cmd.Flag(flags.Separator))
+
+	return &generate.Policy{
+		Kind:             viper.GetString(flags.Kind),
+		Length:           viper.GetInt(flags.Length),
+		Classes:          viper.GetStringSlice(flags.Charset),
+		ExcludeAmbiguous: viper.GetBool(flags.ExcludeAmbiguous),
+		Words:            viper.GetInt(flags.Words),
+		Separator:        viper.GetString(flags.Separator),
+	}
+}
+
+// setSecret writes a new version of a secret using value from args,
+// from input or generated as per policy
+func setSecret(cmd *cobra.Command, args []string, gen bool) error {
 	ctx := cmd.Context()
 	persistentFlags := getPersistentFlags(cmd)
 
 	_ = viper.BindPFlag(flags.Name, cmd.Flag(flags.Name))
 	_ = viper.BindPFlag(flags.Encrypt, cmd.Flag(flags.Encrypt))
-	_ = viper.BindPFlag(flags.Passphrase, cmd.Flag(flags.Passphrase))
 	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+	_ = viper.BindPFlag(flags.Type, cmd.Flag(flags.Type))
+	_ = viper.BindPFlag(flags.Language, cmd.Flag(flags.Language))
+	_ = viper.BindPFlag(flags.File, cmd.Flag(flags.File))
 
 	name := viper.GetString(flags.Name)
 	encrypt := viper.GetBool(flags.Encrypt)
-	passphrase := viper.GetString(flags.Passphrase)
 	noPrompt := viper.GetBool(flags.NoPrompt)
+	secretType := viper.GetString(flags.Type)
+	language := viper.GetString(flags.Language)
+	filename := viper.GetString(flags.File)
+
+	if !gen && len(filename) == 0 && len(args) == 0 && passphraseFromStdin(cmd) {
+		return fmt.Errorf("--%s 0 cannot be used when secret value is read from stdin", flags.PassphraseFd)
+	}
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := validateSecretType(secretType); err != nil {
+		return err
+	}
 
 	// enforce encryption if passphrase is explicitly provided
 	if len(passphrase) > 0 {
 		encrypt = true
 	}
 
-	prompt, err := prompts.Status()
+	prompt, err := newPrompter(cmd, noPrompt)
 	if err != nil {
-		return fmt.Errorf("failed to get prompt status: %w", err)
-	}
-
-	if noPrompt {
-		prompt = false
+		return err
 	}
 
 	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
@@ -64,154 +103,172 @@ func Set(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("please input value for --name flag")
 	}
 
-	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
-		return fmt.Errorf("invalid name, need DNS1123Label format: %v", errs)
+	if gen && (len(args) > 0 || len(filename) > 0) {
+		return fmt.Errorf("secret value cannot be provided when generating it")
+	}
+
+	if len(filename) > 0 && len(args) > 0 {
+		return fmt.Errorf("secret value cannot be provided both as args and file")
+	}
+
+	if err := namespace.Validate(name); err != nil {
+		return err
 	}
 
 	// Create the client.
-	client, err := secretmanager.NewClient(ctx)
+	client, err := newClient(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to create secret manager client: %w", err)
 	}
 	defer client.Close()
 
-	labels := map[string]string{
-		app.KeyManagedBy: app.Name,
-	}
-	if encrypt {
-		labels[app.KeyEncrypted] = app.ValueTrue
+	writer := &secretWriter{
+		client:  client,
+		project: persistentFlags.Project,
 	}
 
-	// Create the request to create the secret.
-	createSecretReq := &secretmanagerpb.CreateSecretRequest{
-		Parent:   fmt.Sprintf("projects/%s", persistentFlags.Project),
-		SecretId: name,
-		Secret: &secretmanagerpb.Secret{
-			Replication: &secretmanagerpb.Replication{
-				Replication: &secretmanagerpb.Replication_Automatic_{
-					Automatic: &secretmanagerpb.Replication_Automatic{},
-				},
-			},
-			Labels: labels,
-		},
+	var labels map[string]string
+	if len(secretType) > 0 {
+		labels = map[string]string{app.KeyType: secretType}
 	}
 
-	secret, err := client.CreateSecret(ctx, createSecretReq)
+	secret, encrypt, err := writer.ensure(ctx, name, encrypt, labels)
 	if err != nil {
-		apiErr, ok := err.(*apierror.APIError)
-		if ok {
-			if apiErr.GRPCStatus().Code() == codes.AlreadyExists {
-				secret, err = client.GetSecret(
-					ctx,
-					&secretmanagerpb.GetSecretRequest{
-						Name: fmt.Sprintf("projects/%s/secrets/%s", persistentFlags.Project, name),
-					},
-				)
-				if err != nil {
-					return fmt.Errorf("failed to get secret: %w", err)
-				}
-			} else {
-				return fmt.Errorf("failed to create secret: %w", err)
-			}
-		} else {
-			return fmt.Errorf("failed to create a secret: %T, %w", err, err)
-		}
+		return err
 	}
 
-	labels = secret.GetLabels()
-	if value, ok := labels[app.KeyManagedBy]; !ok || value != app.Name {
-		return fmt.Errorf("secret is not being managed by this app")
-	}
-	if value, ok := labels[app.KeyEncrypted]; ok && value == app.ValueTrue {
-		encrypt = true
-	}
-	if encrypt {
-		if value, ok := labels[app.KeyEncrypted]; !ok || value != app.ValueTrue {
-			return fmt.Errorf("secret was not previously encrypted and this property is immutable")
+	if existingType := secret.GetLabels()[app.KeyType]; existingType != secretType {
+		if len(secretType) > 0 {
+			return fmt.Errorf("secret %s was previously set with type %q and this property is immutable", name, existingType)
 		}
+		secretType = existingType
 	}
 
 	var secretInput string
 	var key []byte
 
-	if len(args) > 0 {
-		secretInput = strings.Join(args, " ")
-	} else {
-		if prompt {
-			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Enter secret as a string: "); err != nil {
-				return fmt.Errorf("failed to write to output: %w", err)
-			}
+	if gen && secretType == app.TypeMnemonic {
+		_ = viper.BindPFlag(flags.Length, cmd.Flag(flags.Length))
+		secretInput, err = newMnemonic(viper.GetInt(flags.Length), language)
+		if err != nil {
+			return err
+		}
+	} else if gen && secretType == app.TypeTls {
+		reader := &secretReader{
+			client:     client,
+			project:    persistentFlags.Project,
+			passphrase: passphrase,
+			prompt:     prompt,
 		}
 
-		secretInput, err = keys.Read(cmd.InOrStdin())
+		secretInput, err = newTlsBundle(ctx, cmd, reader)
+		if err != nil {
+			return err
+		}
+	} else if gen {
+		secretInput, err = generate.New(generatePolicy(cmd))
+		if err != nil {
+			return fmt.Errorf("failed to generate secret: %w", err)
+		}
+	} else if len(filename) > 0 {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("failed to read secret file: %w", err)
+		}
+		secretInput = string(b)
+	} else if len(args) > 0 {
+		secretInput = strings.Join(args, " ")
+	} else if secretType == app.TypeTls {
+		b, err := prompt.All("Enter PEM encoded certificate and key, end with Ctrl-D")
+		if err != nil {
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+		secretInput = string(b)
+	} else {
+		secretInput, err = prompt.Line("Enter secret as a string")
 		if err != nil {
 			return fmt.Errorf("failed to read secret: %w", err)
 		}
 	}
 
-	if encrypt {
-		if _, err := fmt.Fprintln(cmd.OutOrStdout(), "This input will be encrypted using your password"); err != nil {
-			return fmt.Errorf("failed to write to output: %w", err)
+	if secretType == app.TypeMnemonic {
+		secretInput, err = tidyMnemonic(secretInput, language)
+		if err != nil {
+			return err
 		}
-		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Enter encryption password (min 8 char): "); err != nil {
-			return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	var notAfter time.Time
+	if secretType == app.TypeTls {
+		notAfter, err = validateTlsBundle([]byte(secretInput))
+		if err != nil {
+			return err
 		}
+	}
 
-		if len(passphrase) == 0 {
-			encryptionKey, err := term.ReadPassword(syscall.Stdin)
+	// agent, if available, is consulted before prompting for passphrase
+	// and encrypts with the key protecting the latest version, whereas
+	// passphrase of a new secret is always prompted for
+	var keyAgent *agent.Client
+	var ciphertext []byte
+	if encrypt && len(passphrase) == 0 {
+		if c, ok := agent.NewClientFromEnv(); ok {
+			versions, err := listVersions(ctx, client, secret.GetName(), "state:ENABLED")
 			if err != nil {
-				return fmt.Errorf("failed to read encryption password from input: %w", err)
-			}
-			if _, err := fmt.Fprintln(cmd.OutOrStdout()); err != nil {
-				return fmt.Errorf("failed to write to output: %w", err)
+				return err
 			}
 
-			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Enter encryption password again: "); err != nil {
-				return fmt.Errorf("failed to write to output: %w", err)
+			if len(versions) > 0 {
+				if ciphertext, err = agentEncrypt(ctx, client, c, versions[len(versions)-1].GetName(), []byte(secretInput)); err == nil {
+					keyAgent = c
+				}
 			}
-			encryptionKeyConfirm, err := term.ReadPassword(syscall.Stdin)
+		}
+	}
+
+	if encrypt && keyAgent == nil {
+		if len(passphrase) == 0 {
+			passphrase, err = promptNewPassphrase(prompt)
 			if err != nil {
-				return fmt.Errorf("failed to read encryption password from input: %w", err)
-			}
-			if _, err := fmt.Fprintln(cmd.OutOrStdout()); err != nil {
-				return fmt.Errorf("failed to write to output: %w", err)
+				return err
 			}
+		}
 
-			if !bytes.Equal(encryptionKey, encryptionKeyConfirm) {
-				return fmt.Errorf("passwords do not match")
-			}
+		newPassphrase, err := isNewPassphrase(ctx, client, secret, passphrase)
+		if err != nil {
+			return err
+		}
 
-			passphrase = string(encryptionKey)
+		if newPassphrase {
+			if err := checkPassphrase(passphrase, []byte(secretInput)); err != nil {
+				return err
+			}
 		}
 
 		key, err = crypto.NewAesKeyFromPassphrase([]byte(passphrase))
 		if err != nil {
 			return fmt.Errorf("failed to generate new AES key: %w", err)
 		}
-
-		in, err := crypto.EncryptWithAesKey([]byte(secretInput), key)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt input: %w", err)
-		}
-
-		secretInput = base58.Encode(in)
 	}
 
-	// Build the request.
-	data := []byte(secretInput)
-	dataCrc32C := int64(Crc32Sum(data))
-	addSecretVersionReq := &secretmanagerpb.AddSecretVersionRequest{
-		Parent: secret.Name,
-		Payload: &secretmanagerpb.SecretPayload{
-			Data:       data,
-			DataCrc32C: &dataCrc32C,
-		},
+	var version *secretmanagerpb.SecretVersion
+	if keyAgent != nil {
+		version, err = addStoredVersion(ctx, client, secret.GetName(), []byte(base58.Encode(ciphertext)), false)
+	} else {
+		version, err = writer.add(ctx, secret, []byte(secretInput), key)
 	}
-
-	// Call the API.
-	version, err := client.AddSecretVersion(ctx, addSecretVersionReq)
 	if err != nil {
-		return fmt.Errorf("failed to add secret version: %w", err)
+		return err
+	}
+
+	if secretType == app.TypeTls {
+		if _, err := writer.setLabels(
+			ctx,
+			secret,
+			map[string]string{app.KeyNotAfter: notAfterLabel(notAfter)},
+		); err != nil {
+			return err
+		}
 	}
 
 	// Build the request.
@@ -232,7 +289,11 @@ func Set(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to base58 decode stored value: %w", err)
 		}
 
-		payload, err = crypto.DecryptWithAesKey(ciphertext, key)
+		if keyAgent != nil {
+			payload, err = keyAgent.Decrypt(ciphertext)
+		} else {
+			payload, err = crypto.DecryptWithAesKey(ciphertext, key)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to decrypt data: %w", err)
 		}
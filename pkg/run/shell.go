@@ -0,0 +1,569 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/kubetrail/mksecret/pkg/picker"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+)
+
+const shellPrompt = "mksecret> "
+
+// shellCommands lists usage and description of shell commands
+var shellCommands = map[string][2]string{
+	"ls":       {"ls [PREFIX]", "list secrets"},
+	"get":      {"get NAME [VERSION]", "print value of a secret"},
+	"set":      {"set [--encrypt] NAME", "add a new version, value is read without echo"},
+	"versions": {"versions NAME", "list versions of a secret"},
+	"describe": {"describe NAME", "show labels and versions of a secret"},
+	"rm":       {"rm NAME", "delete a secret"},
+	"help":     {"help", "show this help"},
+	"exit":     {"exit", "leave the shell"},
+}
+
+// shellPrompter prompts on shell terminal. Passwords and secret values
+// are read without echo and are therefore not recorded in shell history.
+type shellPrompter struct {
+	t *term.Terminal
+}
+
+func (p *shellPrompter) Print(message string) error {
+	if _, err := fmt.Fprintln(p.t, message); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
+
+func (p *shellPrompter) Line(message string) (string, error) {
+	p.t.SetPrompt(message + ": ")
+	defer p.t.SetPrompt(shellPrompt)
+
+	line, err := p.t.ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("failed to read from input: %w", err)
+	}
+
+	return line, nil
+}
+
+func (p *shellPrompter) Password(message string) ([]byte, error) {
+	password, err := p.t.ReadPassword(message + ": ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password from input: %w", err)
+	}
+
+	return []byte(password), nil
+}
+
+func (p *shellPrompter) All(message string) ([]byte, error) {
+	return nil, fmt.Errorf("multi-line input is not supported in shell")
+}
+
+// shell is an interactive session sharing one client. Passphrases that
+// decrypted a secret are kept for the session and tried before prompting.
+type shell struct {
+	ctx         context.Context
+	client      *secretmanager.Client
+	project     string
+	t           *term.Terminal
+	prompt      *shellPrompter
+	passphrases []string
+	names       []string
+}
+
+func Shell(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	if passphraseFromStdin(cmd) {
+		return fmt.Errorf("--%s 0 cannot be used in interactive shell", flags.PassphraseFd)
+	}
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if !picker.IsTerminal(cmd.InOrStdin()) {
+		return fmt.Errorf("shell needs a terminal")
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(
+		struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, cmd.OutOrStdout()},
+		shellPrompt,
+	)
+
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		_ = t.SetSize(width, height)
+	}
+
+	s := &shell{
+		ctx:     ctx,
+		client:  client,
+		project: persistentFlags.Project,
+		t:       t,
+		prompt:  &shellPrompter{t: t},
+	}
+
+	if len(passphrase) > 0 {
+		s.passphrases = append(s.passphrases, passphrase)
+	}
+
+	t.AutoCompleteCallback = s.complete
+
+	for {
+		line, err := t.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read from input: %w", err)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "exit" || fields[0] == "quit" {
+			return nil
+		}
+
+		if err := s.run(fields[0], fields[1:]); err != nil {
+			_, _ = fmt.Fprintf(t, "Error: %v\n", err)
+		}
+	}
+}
+
+// run executes a shell command
+func (s *shell) run(command string, args []string) error {
+	nameArg := func() (string, error) {
+		if len(args) == 0 {
+			return "", fmt.Errorf("please provide name of the secret, usage: %s", shellCommands[command][0])
+		}
+		return args[0], nil
+	}
+
+	switch command {
+	case "help":
+		return s.help()
+	case "ls":
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+		return s.list(prefix)
+	case "get":
+		name, err := nameArg()
+		if err != nil {
+			return err
+		}
+
+		version := "latest"
+		if len(args) > 1 {
+			version = args[1]
+		}
+
+		value, _, err := s.read(name, version)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(s.t, string(value.Payload))
+		return err
+	case "set":
+		encrypt := viper.GetBool(flags.Encrypt)
+		if len(args) > 0 && (args[0] == "--encrypt" || args[0] == "-e") {
+			encrypt = true
+			args = args[1:]
+		}
+
+		// values on command line would end up in shell history
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s", shellCommands[command][0])
+		}
+
+		return s.set(args[0], encrypt)
+	case "versions":
+		name, err := nameArg()
+		if err != nil {
+			return err
+		}
+		return s.versions(name)
+	case "describe":
+		name, err := nameArg()
+		if err != nil {
+			return err
+		}
+		return s.describe(name)
+	case "rm":
+		name, err := nameArg()
+		if err != nil {
+			return err
+		}
+		return s.remove(name)
+	}
+
+	return fmt.Errorf("unknown command %s, type help for a list of commands", command)
+}
+
+func (s *shell) help() error {
+	commands := make([]string, 0, len(shellCommands))
+	for command := range shellCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	w := tabwriter.NewWriter(s.t, 0, 4, 4, ' ', 0)
+	for _, command := range commands {
+		if _, err := fmt.Fprintf(w, "  %s\t%s\n", shellCommands[command][0], shellCommands[command][1]); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// refreshNames caches names of managed secrets for completion
+func (s *shell) refreshNames() ([]*secretmanagerpb.Secret, error) {
+	secrets, err := listSecrets(s.ctx, s.client, s.project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].GetName() < secrets[j].GetName()
+	})
+
+	s.names = make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		s.names = append(s.names, logicalName(secret))
+	}
+
+	return secrets, nil
+}
+
+func (s *shell) list(prefix string) error {
+	secrets, err := s.refreshNames()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(s.t, 0, 4, 2, ' ', 0)
+	for _, secret := range secrets {
+		name := logicalName(secret)
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		detail, err := secretDetail(s.ctx, s.client, secret)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", name, detail); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// read reads a version of a secret trying passphrases cached in the
+// session before consulting the agent and prompting for a passphrase.
+// Passphrase that decrypted the value is returned, which is empty if
+// the value was not encrypted or was decrypted by the agent.
+func (s *shell) read(name, version string) (*secretValue, string, error) {
+	secret, err := getManagedSecret(s.ctx, s.client, s.project, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader := &secretReader{
+		client:  s.client,
+		project: s.project,
+		prompt:  s.prompt,
+	}
+
+	if secret.GetLabels()[app.KeyEncrypted] == app.ValueTrue {
+		for i := len(s.passphrases) - 1; i >= 0; i-- {
+			reader.passphrase = s.passphrases[i]
+			if value, err := reader.read(s.ctx, name, version); err == nil {
+				return value, reader.passphrase, nil
+			}
+		}
+		reader.passphrase = ""
+	}
+
+	value, err := reader.read(s.ctx, name, version)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(reader.passphrase) > 0 {
+		s.passphrases = append(s.passphrases, reader.passphrase)
+	}
+
+	return value, reader.passphrase, nil
+}
+
+func (s *shell) set(name string, encrypt bool) error {
+	if err := namespace.Validate(name); err != nil {
+		return err
+	}
+
+	writer := &secretWriter{
+		client:  s.client,
+		project: s.project,
+	}
+
+	secret, encrypt, err := writer.ensure(s.ctx, name, encrypt, nil)
+	if err != nil {
+		return err
+	}
+
+	input, err := s.prompt.Password("Enter secret value")
+	if err != nil {
+		return err
+	}
+
+	if len(input) == 0 {
+		return fmt.Errorf("secret value cannot be empty")
+	}
+
+	if !encrypt {
+		_, err := writer.add(s.ctx, secret, input, nil)
+		return err
+	}
+
+	// new value is encrypted using passphrase of the latest version
+	passphrase := ""
+	versions, err := listVersions(s.ctx, s.client, secret.GetName(), "state:ENABLED")
+	if err != nil {
+		return err
+	}
+
+	if len(versions) > 0 {
+		_, passphrase, err = s.read(name, path.Base(versions[len(versions)-1].GetName()))
+		if err != nil {
+			return err
+		}
+
+		// latest version was decrypted by the agent, so it holds the
+		// key protecting it
+		if len(passphrase) == 0 {
+			keyAgent, err := agentClient()
+			if err != nil {
+				return err
+			}
+
+			ciphertext, err := agentEncrypt(s.ctx, s.client, keyAgent, versions[len(versions)-1].GetName(), input)
+			if err != nil {
+				return err
+			}
+
+			_, err = addStoredVersion(s.ctx, s.client, secret.GetName(), []byte(base58.Encode(ciphertext)), false)
+			return err
+		}
+	}
+
+	if len(passphrase) == 0 {
+		passphrase, err = promptNewPassphrase(s.prompt)
+		if err != nil {
+			return err
+		}
+
+		if err := checkPassphrase(passphrase, input); err != nil {
+			return err
+		}
+		s.passphrases = append(s.passphrases, passphrase)
+	}
+
+	key, err := crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+	if err != nil {
+		return fmt.Errorf("failed to generate new AES key: %w", err)
+	}
+
+	_, err = writer.add(s.ctx, secret, input, key)
+	return err
+}
+
+func (s *shell) versions(name string) error {
+	secret, err := getManagedSecret(s.ctx, s.client, s.project, name)
+	if err != nil {
+		return err
+	}
+
+	versions, err := listVersions(s.ctx, s.client, secret.GetName(), "")
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(s.t, 0, 4, 2, ' ', 0)
+	for _, version := range versions {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n",
+			path.Base(version.GetName()),
+			version.GetState().String(),
+			version.GetCreateTime().AsTime().Local().Format("2006-01-02 15:04:05"),
+		); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+func (s *shell) describe(name string) error {
+	secret, err := getManagedSecret(s.ctx, s.client, s.project, name)
+	if err != nil {
+		return err
+	}
+
+	versions, err := listVersions(s.ctx, s.client, secret.GetName(), "state:ENABLED")
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(secret.GetLabels()))
+	for k := range secret.GetLabels() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(s.t, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "name:\t%s\n", name)
+	_, _ = fmt.Fprintf(w, "created:\t%s\n", secret.GetCreateTime().AsTime().Local().Format("2006-01-02 15:04:05"))
+	_, _ = fmt.Fprintf(w, "enabled versions:\t%d\n", len(versions))
+	if len(versions) > 0 {
+		_, _ = fmt.Fprintf(w, "latest version:\t%s\n", path.Base(versions[len(versions)-1].GetName()))
+	}
+	_, _ = fmt.Fprintln(w, "labels:\t")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(w, "  %s:\t%s\n", k, secret.GetLabels()[k])
+	}
+
+	return w.Flush()
+}
+
+func (s *shell) remove(name string) error {
+	secret, err := getManagedSecret(s.ctx, s.client, s.project, name)
+	if err != nil {
+		return err
+	}
+
+	input, err := s.prompt.Line("Type secret name to delete")
+	if err != nil {
+		return err
+	}
+
+	if input != name {
+		return fmt.Errorf("input does not match secret name")
+	}
+
+	if err := s.client.DeleteSecret(
+		s.ctx,
+		&secretmanagerpb.DeleteSecretRequest{
+			Name: secret.GetName(),
+		},
+	); err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	_, err = s.refreshNames()
+	return err
+}
+
+// complete completes command names and secret names on tab
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	fields := strings.Fields(prefix)
+	if strings.HasSuffix(prefix, " ") || len(fields) == 0 {
+		fields = append(fields, "")
+	}
+
+	var candidates []string
+	if len(fields) == 1 {
+		for command := range shellCommands {
+			candidates = append(candidates, command)
+		}
+	} else {
+		if s.names == nil {
+			if _, err := s.refreshNames(); err != nil {
+				return "", 0, false
+			}
+		}
+		candidates = s.names
+	}
+
+	word := fields[len(fields)-1]
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+
+	completion := matches[0]
+	for _, match := range matches[1:] {
+		for !strings.HasPrefix(match, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+
+	if len(matches) == 1 {
+		completion += " "
+	} else if completion == word {
+		_, _ = fmt.Fprintln(s.t, strings.Join(matches, "  "))
+		return "", 0, false
+	}
+
+	newPrefix := prefix[:len(prefix)-len(word)] + completion
+	return newPrefix + line[pos:], len(newPrefix), true
+}
@@ -0,0 +1,261 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/kubetrail/mksecret/pkg/sshkeys"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// sshPublicKeyName is the name of sibling secret holding
+// public key of an ssh key secret
+func sshPublicKeyName(name string) string {
+	return name + "-pub"
+}
+
+func SshKeygen(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Name, cmd.Flag(flags.Name))
+	_ = viper.BindPFlag(flags.Type, cmd.Flag(flags.Type))
+	_ = viper.BindPFlag(flags.Bits, cmd.Flag(flags.Bits))
+	_ = viper.BindPFlag(flags.Comment, cmd.Flag(flags.Comment))
+	_ = viper.BindPFlag(flags.Encrypt, cmd.Flag(flags.Encrypt))
+
+	name := viper.GetString(flags.Name)
+	keyType := viper.GetString(flags.Type)
+	bits := viper.GetInt(flags.Bits)
+	comment := viper.GetString(flags.Comment)
+	encrypt := viper.GetBool(flags.Encrypt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	// enforce encryption if passphrase is explicitly provided
+	if len(passphrase) > 0 {
+		encrypt = true
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	if len(name) == 0 {
+		return fmt.Errorf("please input value for --name flag")
+	}
+
+	pubName := sshPublicKeyName(name)
+	if err := namespace.Validate(pubName); err != nil {
+		return fmt.Errorf("invalid public key secret name: %w", err)
+	}
+
+	keyPair, err := sshkeys.New(keyType, bits, comment)
+	if err != nil {
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	writer := &secretWriter{
+		client:  client,
+		project: persistentFlags.Project,
+	}
+
+	secret, encrypt, err := writer.ensure(ctx, name, encrypt, map[string]string{app.KeyType: app.TypeSshKey})
+	if err != nil {
+		return err
+	}
+
+	if secretType := secret.GetLabels()[app.KeyType]; secretType != app.TypeSshKey {
+		return fmt.Errorf("secret %s exists and is not of type %s", name, app.TypeSshKey)
+	}
+
+	pubSecret, err := writer.ensurePlain(ctx, pubName, map[string]string{app.KeyType: app.TypeSshPub})
+	if err != nil {
+		return err
+	}
+
+	if secretType := pubSecret.GetLabels()[app.KeyType]; secretType != app.TypeSshPub {
+		return fmt.Errorf("secret %s exists and is not of type %s", pubName, app.TypeSshPub)
+	}
+
+	var key []byte
+	if encrypt {
+		if len(passphrase) == 0 {
+			prompt, err := newPrompter(cmd, false)
+			if err != nil {
+				return err
+			}
+
+			passphrase, err = promptNewPassphrase(prompt)
+			if err != nil {
+				return err
+			}
+		}
+
+		newPassphrase, err := isNewPassphrase(ctx, client, secret, passphrase)
+		if err != nil {
+			return err
+		}
+
+		if newPassphrase {
+			if err := checkPassphrase(passphrase, []byte(keyPair.PrivateKey)); err != nil {
+				return err
+			}
+		}
+
+		key, err = crypto.NewAesKeyFromPassphrase([]byte(passphrase))
+		if err != nil {
+			return fmt.Errorf("failed to generate new AES key: %w", err)
+		}
+	}
+
+	version, err := writer.add(ctx, secret, keyPair.PrivateKey, key)
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.add(ctx, pubSecret, keyPair.PublicKey, nil); err != nil {
+		return err
+	}
+
+	publicKey := strings.TrimSpace(string(keyPair.PublicKey))
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), publicKey); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatJson, flags.OutputFormatYaml:
+		out := struct {
+			Name          string `json:"name" yaml:"name"`
+			Version       string `json:"version" yaml:"version"`
+			PublicKeyName string `json:"publicKeyName" yaml:"publicKeyName"`
+			PublicKey     string `json:"publicKey" yaml:"publicKey"`
+			Fingerprint   string `json:"fingerprint" yaml:"fingerprint"`
+		}{
+			Name:          name,
+			Version:       path.Base(version.GetName()),
+			PublicKeyName: pubName,
+			PublicKey:     publicKey,
+			Fingerprint:   keyPair.Fingerprint,
+		}
+
+		if persistentFlags.OutputFormat == flags.OutputFormatJson {
+			jb, err := json.Marshal(out)
+			if err != nil {
+				return fmt.Errorf("failed to serialize output json: %w", err)
+			}
+
+			if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		} else {
+			jb, err := yaml.Marshal(out)
+			if err != nil {
+				return fmt.Errorf("failed to serialize output yaml: %w", err)
+			}
+
+			if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Version", "Public Key Name", "Fingerprint"})
+		table.Append(
+			[]string{
+				name,
+				path.Base(version.GetName()),
+				pubName,
+				keyPair.Fingerprint,
+			},
+		)
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
+
+// SshAdd writes private key of an ssh key secret in OpenSSH format
+// irrespective of output format so that it can be piped to ssh-add
+func SshAdd(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Version, cmd.Flag(flags.Version))
+	_ = viper.BindPFlag(flags.NoPrompt, cmd.Flag(flags.NoPrompt))
+
+	name := args[0]
+	version := viper.GetString(flags.Version)
+	noPrompt := viper.GetBool(flags.NoPrompt)
+
+	passphrase, err := getPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := newPrompter(cmd, noPrompt)
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	reader := &secretReader{
+		client:     client,
+		project:    persistentFlags.Project,
+		passphrase: passphrase,
+		prompt:     prompt,
+	}
+
+	value, err := reader.read(ctx, name, version)
+	if err != nil {
+		return err
+	}
+
+	if value.Labels[app.KeyType] != app.TypeSshKey {
+		return fmt.Errorf("secret %s is not of type %s", name, app.TypeSshKey)
+	}
+
+	if _, err := sshkeys.Validate(value.Payload); err != nil {
+		return err
+	}
+
+	if _, err := cmd.OutOrStdout().Write(value.Payload); err != nil {
+		return fmt.Errorf("failed to write to output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/tlscerts"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// notAfterLabel formats certificate expiry as label value, which
+// is unix time since label values cannot hold RFC3339 timestamps
+func notAfterLabel(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// parseNotAfterLabel parses certificate expiry from label value
+func parseNotAfterLabel(value string) (time.Time, error) {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s label value %s: %w", app.KeyNotAfter, value, err)
+	}
+
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// parseWithin parses a duration also accepting days, such as 30d
+func parseWithin(input string) (time.Duration, error) {
+	if strings.HasSuffix(input, "d") {
+		n, err := strconv.ParseUint(strings.TrimSuffix(input, "d"), 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s: %w", input, err)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s: %w", input, err)
+	}
+
+	return d, nil
+}
+
+// validateTlsBundle parses a PEM bundle, ensures that private key
+// matches leaf certificate and returns expiry of leaf certificate
+func validateTlsBundle(bundle []byte) (time.Time, error) {
+	cert, _, err := tlscerts.Parse(bundle)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cert.NotAfter, nil
+}
+
+// newTlsBundle generates a certificate and key as per flags. Certificate
+// is self-signed unless a ca secret of type tls is provided.
+func newTlsBundle(ctx context.Context, cmd *cobra.Command, reader *secretReader) (string, error) {
+	_ = viper.BindPFlag(flags.CommonName, cmd.Flag(flags.CommonName))
+	_ = viper.BindPFlag(flags.Hosts, cmd.Flag(flags.Hosts))
+	_ = viper.BindPFlag(flags.ValidFor, cmd.Flag(flags.ValidFor))
+	_ = viper.BindPFlag(flags.Ca, cmd.Flag(flags.Ca))
+	_ = viper.BindPFlag(flags.IsCa, cmd.Flag(flags.IsCa))
+
+	caName := viper.GetString(flags.Ca)
+
+	validFor, err := parseWithin(viper.GetString(flags.ValidFor))
+	if err != nil {
+		return "", err
+	}
+
+	opts := &tlscerts.Options{
+		CommonName: viper.GetString(flags.CommonName),
+		Hosts:      viper.GetStringSlice(flags.Hosts),
+		ValidFor:   validFor,
+		IsCa:       viper.GetBool(flags.IsCa),
+	}
+
+	if len(caName) == 0 {
+		bundle, err := tlscerts.New(opts, nil, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate certificate: %w", err)
+		}
+		return string(bundle), nil
+	}
+
+	value, err := reader.read(ctx, caName, "latest")
+	if err != nil {
+		return "", err
+	}
+
+	if value.Labels[app.KeyType] != app.TypeTls {
+		return "", fmt.Errorf("ca secret %s is not of type %s", caName, app.TypeTls)
+	}
+
+	ca, caKey, err := tlscerts.Parse(value.Payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse ca secret %s: %w", caName, err)
+	}
+
+	bundle, err := tlscerts.New(opts, ca, caKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate certificate: %w", err)
+	}
+
+	return string(bundle), nil
+}
+
+// expiringCert is a tls secret expiring within requested duration
+type expiringCert struct {
+	Name     string    `json:"name" yaml:"name"`
+	NotAfter time.Time `json:"notAfter" yaml:"notAfter"`
+	Expired  bool      `json:"expired" yaml:"expired"`
+}
+
+func CertsExpiring(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	persistentFlags := getPersistentFlags(cmd)
+
+	_ = viper.BindPFlag(flags.Within, cmd.Flag(flags.Within))
+	_ = viper.BindPFlag(flags.Selector, cmd.Flag(flags.Selector))
+
+	selector := viper.GetString(flags.Selector)
+	within, err := parseWithin(viper.GetString(flags.Within))
+	if err != nil {
+		return err
+	}
+
+	if err := setAppCredsEnvVar(persistentFlags.ApplicationCredentials); err != nil {
+		err := fmt.Errorf("could not set Google Application credentials env. var: %w", err)
+		return err
+	}
+
+	typeSelector := fmt.Sprintf("%s=%s", app.KeyType, app.TypeTls)
+	if len(selector) > 0 {
+		typeSelector = typeSelector + "," + selector
+	}
+
+	// Create the client.
+	client, err := newClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager client: %w", err)
+	}
+	defer client.Close()
+
+	secrets, err := listSecrets(ctx, client, persistentFlags.Project, typeSelector)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deadline := now.Add(within)
+	certs := make([]expiringCert, 0, len(secrets))
+	for _, secret := range secrets {
+		value, ok := secret.GetLabels()[app.KeyNotAfter]
+		if !ok {
+			continue
+		}
+
+		notAfter, err := parseNotAfterLabel(value)
+		if err != nil {
+			return fmt.Errorf("secret %s: %w", logicalName(secret), err)
+		}
+
+		if notAfter.After(deadline) {
+			continue
+		}
+
+		certs = append(
+			certs,
+			expiringCert{
+				Name:     logicalName(secret),
+				NotAfter: notAfter,
+				Expired:  !notAfter.After(now),
+			},
+		)
+	}
+
+	sort.Slice(certs, func(i, j int) bool {
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+
+	switch persistentFlags.OutputFormat {
+	case flags.OutputFormatNative:
+		for _, cert := range certs {
+			status := fmt.Sprintf("expires in %s", cert.NotAfter.Sub(now).Round(time.Hour))
+			if cert.Expired {
+				status = "expired"
+			}
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s %s %s\n",
+				cert.Name, cert.NotAfter.Format(time.RFC3339), status); err != nil {
+				return fmt.Errorf("failed to write to output: %w", err)
+			}
+		}
+	case flags.OutputFormatJson:
+		jb, err := json.Marshal(certs)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output json: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatYaml:
+		jb, err := yaml.Marshal(certs)
+		if err != nil {
+			return fmt.Errorf("failed to serialize output yaml: %w", err)
+		}
+
+		if _, err := fmt.Fprint(cmd.OutOrStdout(), string(jb)); err != nil {
+			return fmt.Errorf("failed to write to output: %w", err)
+		}
+	case flags.OutputFormatTable:
+		table := tablewriter.NewWriter(cmd.OutOrStdout())
+		table.SetHeader([]string{"Name", "Not After", "Expired"})
+		for _, cert := range certs {
+			table.Append(
+				[]string{
+					cert.Name,
+					cert.NotAfter.Format(time.RFC3339),
+					strconv.FormatBool(cert.Expired),
+				},
+			)
+		}
+		table.SetBorder(false)
+		table.SetColumnSeparator(" ")
+		table.Render() // Send output
+	}
+
+	return nil
+}
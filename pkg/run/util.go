@@ -1,13 +1,17 @@
 package run
 
 import (
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"os"
 
+	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/kubetrail/mksecret/pkg/app"
 	"github.com/kubetrail/mksecret/pkg/flags"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
 )
 
 type persistentFlagValues struct {
@@ -52,3 +56,35 @@ func Crc32Sum(data []byte) uint32 {
 	t := crc32.MakeTable(crc32.Castagnoli)
 	return crc32.Checksum(data, t)
 }
+
+// isNotFound checks if error was caused by a missing resource
+func isNotFound(err error) bool {
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.GRPCStatus().Code() == codes.NotFound
+	}
+
+	return false
+}
+
+// secretTypes are valid values of type label
+var secretTypes = []string{
+	app.TypeMnemonic,
+	app.TypeTls,
+}
+
+// validateSecretType ensures that secret type is supported.
+// Empty type denotes a plain secret.
+func validateSecretType(secretType string) error {
+	if len(secretType) == 0 {
+		return nil
+	}
+
+	for _, t := range secretTypes {
+		if t == secretType {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid secret type %s, valid types are %v", secretType, secretTypes)
+}
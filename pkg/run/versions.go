@@ -0,0 +1,197 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"google.golang.org/api/iterator"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// listVersions lists versions of a secret in ascending order of
+// version number. Filter follows secret manager list filter syntax,
+// for instance state:ENABLED.
+func listVersions(
+	ctx context.Context,
+	client *secretmanager.Client,
+	secretName, filter string,
+) ([]*secretmanagerpb.SecretVersion, error) {
+	it := client.ListSecretVersions(
+		ctx,
+		&secretmanagerpb.ListSecretVersionsRequest{
+			Parent: secretName,
+			Filter: filter,
+		},
+	)
+
+	versions := make([]*secretmanagerpb.SecretVersion, 0, 16)
+	for {
+		version, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of %s: %w", path.Base(secretName), err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		vi, _ := strconv.ParseInt(path.Base(versions[i].GetName()), 10, 64)
+		vj, _ := strconv.ParseInt(path.Base(versions[j].GetName()), 10, 64)
+		return vi < vj
+	})
+
+	return versions, nil
+}
+
+// accessVersion returns payload of a version exactly as stored
+// after verifying its checksum
+func accessVersion(
+	ctx context.Context,
+	client *secretmanager.Client,
+	versionName string,
+) ([]byte, error) {
+	result, err := client.AccessSecretVersion(
+		ctx,
+		&secretmanagerpb.AccessSecretVersionRequest{
+			Name: versionName,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access secret version: %w", err)
+	}
+
+	payload := result.GetPayload().GetData()
+	if crc := result.GetPayload().DataCrc32C; crc != nil && *crc != int64(Crc32Sum(payload)) {
+		return nil, fmt.Errorf("checksum mismatch for %s", versionName)
+	}
+
+	return payload, nil
+}
+
+// addStoredVersion adds payload as a new version exactly as provided,
+// i.e., without encrypting it, and optionally disables it
+func addStoredVersion(
+	ctx context.Context,
+	client *secretmanager.Client,
+	secretName string,
+	payload []byte,
+	disabled bool,
+) (*secretmanagerpb.SecretVersion, error) {
+	dataCrc32C := int64(Crc32Sum(payload))
+	version, err := client.AddSecretVersion(
+		ctx,
+		&secretmanagerpb.AddSecretVersionRequest{
+			Parent: secretName,
+			Payload: &secretmanagerpb.SecretPayload{
+				Data:       payload,
+				DataCrc32C: &dataCrc32C,
+			},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add secret version: %w", err)
+	}
+
+	if disabled {
+		version, err = client.DisableSecretVersion(
+			ctx,
+			&secretmanagerpb.DisableSecretVersionRequest{
+				Name: version.GetName(),
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to disable secret version: %w", err)
+		}
+	}
+
+	return version, nil
+}
+
+// nextRotationTime returns next rotation time that is in the future.
+// A past time is advanced by whole rotation periods, whereas rotation
+// is dropped when it cannot be advanced, since secret manager rejects
+// rotation times in the past.
+func nextRotationTime(next *time.Time, period time.Duration, now time.Time) (time.Time, bool) {
+	switch {
+	case next != nil && next.After(now):
+		return *next, true
+	case period <= 0:
+		return time.Time{}, false
+	case next == nil:
+		return now.Add(period), true
+	}
+
+	periods := now.Sub(*next)/period + 1
+	return next.Add(periods * period), true
+}
+
+// createSecretFrom creates a secret in a project using metadata such as
+// labels, replication, expiration, rotation and topics of a template.
+// Expiration in the past is dropped and rotation is moved to the future
+// or dropped when template has no topics, since it requires topics.
+func createSecretFrom(
+	ctx context.Context,
+	client *secretmanager.Client,
+	project, name string,
+	template *secretmanagerpb.Secret,
+) (*secretmanagerpb.Secret, error) {
+	id, err := namespace.ToID(name)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &secretmanagerpb.Secret{
+		Replication: template.GetReplication(),
+		Labels:      namespaceLabels(template.GetLabels(), name),
+		Topics:      template.GetTopics(),
+	}
+
+	now := time.Now()
+
+	if expireTime := template.GetExpireTime(); expireTime != nil && expireTime.AsTime().After(now) {
+		secret.Expiration = &secretmanagerpb.Secret_ExpireTime{ExpireTime: expireTime}
+	}
+
+	if rotation := template.GetRotation(); rotation != nil && len(secret.Topics) > 0 {
+		var next *time.Time
+		if rotation.GetNextRotationTime() != nil {
+			t := rotation.GetNextRotationTime().AsTime()
+			next = &t
+		}
+
+		if t, ok := nextRotationTime(next, rotation.GetRotationPeriod().AsDuration(), now); ok {
+			secret.Rotation = &secretmanagerpb.Rotation{
+				NextRotationTime: timestamppb.New(t),
+				RotationPeriod:   rotation.GetRotationPeriod(),
+			}
+		}
+	}
+
+	if secret.Replication == nil {
+		secret.Replication = defaultReplication()
+	}
+
+	created, err := client.CreateSecret(
+		ctx,
+		&secretmanagerpb.CreateSecretRequest{
+			Parent:   fmt.Sprintf("projects/%s", project),
+			SecretId: id,
+			Secret:   secret,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret %s: %w", name, err)
+	}
+
+	return created, nil
+}
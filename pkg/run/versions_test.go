@@ -0,0 +1,38 @@
+package run
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRotationTime(t *testing.T) {
+	now := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name   string
+		next   *time.Time
+		period time.Duration
+		want   time.Time
+		wantOk bool
+	}{
+		{name: "future", next: at(day), period: 7 * day, want: now.Add(day), wantOk: true},
+		{name: "future without period", next: at(day), want: now.Add(day), wantOk: true},
+		{name: "past", next: at(-8 * day), period: 7 * day, want: now.Add(6 * day), wantOk: true},
+		{name: "now", next: at(0), period: 7 * day, want: now.Add(7 * day), wantOk: true},
+		{name: "past without period", next: at(-day), wantOk: false},
+		{name: "unset", period: 7 * day, want: now.Add(7 * day), wantOk: true},
+		{name: "unset without period", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := nextRotationTime(tt.next, tt.period, now)
+		if ok != tt.wantOk || !got.Equal(tt.want) {
+			t.Errorf("%s: nextRotationTime() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
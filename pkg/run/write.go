@@ -0,0 +1,245 @@
+package run
+
+import (
+	"context"
+	"fmt"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/kubetrail/mksecret/pkg/app"
+	"github.com/kubetrail/mksecret/pkg/crypto"
+	"github.com/kubetrail/mksecret/pkg/flags"
+	"github.com/kubetrail/mksecret/pkg/namespace"
+	"github.com/mr-tron/base58"
+	"github.com/spf13/viper"
+	secretmanagerpb "google.golang.org/genproto/googleapis/cloud/secretmanager/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// defaultReplication returns replication policy of new secrets using
+// locations from config, if any, else automatic replication
+func defaultReplication() *secretmanagerpb.Replication {
+	locations := viper.GetStringSlice(flags.Replication)
+	if len(locations) == 0 {
+		return &secretmanagerpb.Replication{
+			Replication: &secretmanagerpb.Replication_Automatic_{
+				Automatic: &secretmanagerpb.Replication_Automatic{},
+			},
+		}
+	}
+
+	replicas := make([]*secretmanagerpb.Replication_UserManaged_Replica, 0, len(locations))
+	for _, location := range locations {
+		replicas = append(replicas, &secretmanagerpb.Replication_UserManaged_Replica{Location: location})
+	}
+
+	return &secretmanagerpb.Replication{
+		Replication: &secretmanagerpb.Replication_UserManaged_{
+			UserManaged: &secretmanagerpb.Replication_UserManaged{Replicas: replicas},
+		},
+	}
+}
+
+// secretWriter creates secrets managed by this app and adds
+// new versions to them
+type secretWriter struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// ensure creates named secret if it does not exist, else fetches it.
+// Labels are applied only when the secret is created. Since encryption
+// is immutable, returned encrypt value is true if the secret was
+// previously encrypted and an error is returned if encryption
+// is requested for a secret that was not encrypted previously.
+func (w *secretWriter) ensure(
+	ctx context.Context,
+	name string,
+	encrypt bool,
+	labels map[string]string,
+) (*secretmanagerpb.Secret, bool, error) {
+	if len(name) == 0 {
+		return nil, false, fmt.Errorf("please provide name of the secret")
+	}
+
+	defaults, err := namespaceDefaults(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return w.ensureWithDefaults(ctx, name, encrypt, defaults, labels)
+}
+
+// ensurePlain is like ensure for secrets that are always stored
+// unencrypted, such as public keys. Encryption default of namespace
+// does not apply and an error is returned if the secret was
+// previously encrypted.
+func (w *secretWriter) ensurePlain(
+	ctx context.Context,
+	name string,
+	labels map[string]string,
+) (*secretmanagerpb.Secret, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("please provide name of the secret")
+	}
+
+	defaults, err := namespaceDefaults(name)
+	if err != nil {
+		return nil, err
+	}
+	defaults.Encrypt = false
+
+	secret, encrypt, err := w.ensureWithDefaults(ctx, name, false, defaults, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if encrypt {
+		return nil, fmt.Errorf("secret %s is encrypted and cannot hold unencrypted data", name)
+	}
+
+	return secret, nil
+}
+
+func (w *secretWriter) ensureWithDefaults(
+	ctx context.Context,
+	name string,
+	encrypt bool,
+	defaults namespace.Defaults,
+	labels map[string]string,
+) (*secretmanagerpb.Secret, bool, error) {
+	id, err := namespace.ToID(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	createLabels := map[string]string{
+		app.KeyManagedBy: app.Name,
+	}
+	for k, v := range defaults.Labels {
+		createLabels[k] = v
+	}
+	for k, v := range labels {
+		createLabels[k] = v
+	}
+	if ns, _ := namespace.Split(name); len(ns) > 0 {
+		createLabels[app.KeyNamespace] = namespace.Label(ns)
+	}
+	if encrypt || defaults.Encrypt {
+		createLabels[app.KeyEncrypted] = app.ValueTrue
+	}
+
+	// Create the request to create the secret.
+	createSecretReq := &secretmanagerpb.CreateSecretRequest{
+		Parent:   fmt.Sprintf("projects/%s", w.project),
+		SecretId: id,
+		Secret: &secretmanagerpb.Secret{
+			Replication: defaultReplication(),
+			Labels:      createLabels,
+		},
+	}
+
+	secret, err := w.client.CreateSecret(ctx, createSecretReq)
+	if err != nil {
+		apiErr, ok := err.(*apierror.APIError)
+		if ok {
+			if apiErr.GRPCStatus().Code() == codes.AlreadyExists {
+				secret, err = w.client.GetSecret(
+					ctx,
+					&secretmanagerpb.GetSecretRequest{
+						Name: fmt.Sprintf("projects/%s/secrets/%s", w.project, id),
+					},
+				)
+				if err != nil {
+					return nil, false, fmt.Errorf("failed to get secret: %w", err)
+				}
+			} else {
+				return nil, false, fmt.Errorf("failed to create secret: %w", err)
+			}
+		} else {
+			return nil, false, fmt.Errorf("failed to create a secret: %T, %w", err, err)
+		}
+	}
+
+	existingLabels := secret.GetLabels()
+	if value, ok := existingLabels[app.KeyManagedBy]; !ok || value != app.Name {
+		return nil, false, fmt.Errorf("secret %s is not being managed by this app", name)
+	}
+	if value, ok := existingLabels[app.KeyEncrypted]; ok && value == app.ValueTrue {
+		encrypt = true
+	}
+	if encrypt {
+		if value, ok := existingLabels[app.KeyEncrypted]; !ok || value != app.ValueTrue {
+			return nil, false, fmt.Errorf("secret %s was not previously encrypted and this property is immutable", name)
+		}
+	}
+
+	return secret, encrypt, nil
+}
+
+// add writes data as a new version of the secret. Data is encrypted
+// using the key when key is not nil.
+func (w *secretWriter) add(
+	ctx context.Context,
+	secret *secretmanagerpb.Secret,
+	data, key []byte,
+) (*secretmanagerpb.SecretVersion, error) {
+	if key != nil {
+		in, err := crypto.EncryptWithAesKey(data, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt input: %w", err)
+		}
+
+		data = []byte(base58.Encode(in))
+	}
+
+	// Build the request.
+	dataCrc32C := int64(Crc32Sum(data))
+	addSecretVersionReq := &secretmanagerpb.AddSecretVersionRequest{
+		Parent: secret.Name,
+		Payload: &secretmanagerpb.SecretPayload{
+			Data:       data,
+			DataCrc32C: &dataCrc32C,
+		},
+	}
+
+	// Call the API.
+	version, err := w.client.AddSecretVersion(ctx, addSecretVersionReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add secret version: %w", err)
+	}
+
+	return version, nil
+}
+
+// setLabels merges labels into existing labels of a secret
+func (w *secretWriter) setLabels(
+	ctx context.Context,
+	secret *secretmanagerpb.Secret,
+	labels map[string]string,
+) (*secretmanagerpb.Secret, error) {
+	merged := make(map[string]string)
+	for k, v := range secret.GetLabels() {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	updated, err := w.client.UpdateSecret(
+		ctx,
+		&secretmanagerpb.UpdateSecretRequest{
+			Secret: &secretmanagerpb.Secret{
+				Name:   secret.GetName(),
+				Labels: merged,
+			},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}},
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update labels of %s: %w", logicalName(secret), err)
+	}
+
+	return updated, nil
+}
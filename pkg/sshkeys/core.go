@@ -0,0 +1,194 @@
+package sshkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	TypeEd25519 = "ed25519"
+	TypeRsa     = "rsa"
+)
+
+const DefaultRsaBits = 4096
+
+const (
+	pemType   = "OPENSSH PRIVATE KEY"
+	magic     = "openssh-key-v1\x00"
+	blockSize = 8
+)
+
+// KeyPair is an SSH key pair in OpenSSH formats
+type KeyPair struct {
+	// PrivateKey is PEM encoded in OpenSSH format without encryption
+	PrivateKey []byte
+	// PublicKey is in authorized keys format
+	PublicKey []byte
+	// Fingerprint is SHA256 fingerprint of public key
+	Fingerprint string
+}
+
+// Types returns supported key types
+func Types() []string {
+	return []string{TypeEd25519, TypeRsa}
+}
+
+// New generates a new key pair. Bits only apply to RSA keys.
+func New(keyType string, bits int, comment string) (*KeyPair, error) {
+	var signerKey interface{}
+	var keyData []byte
+
+	switch keyType {
+	case TypeEd25519:
+		pub, prv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+
+		signerKey = prv
+		keyData = ssh.Marshal(
+			struct {
+				KeyType string
+				Pub     []byte
+				Prv     []byte
+				Comment string
+			}{
+				KeyType: ssh.KeyAlgoED25519,
+				Pub:     pub,
+				Prv:     prv,
+				Comment: comment,
+			},
+		)
+	case TypeRsa:
+		if bits == 0 {
+			bits = DefaultRsaBits
+		}
+
+		if bits < 2048 {
+			return nil, fmt.Errorf("rsa key size must be at least 2048 bits, got %d", bits)
+		}
+
+		prv, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		prv.Precompute()
+
+		signerKey = prv
+		keyData = ssh.Marshal(
+			struct {
+				KeyType string
+				N       *big.Int
+				E       *big.Int
+				D       *big.Int
+				Iqmp    *big.Int
+				P       *big.Int
+				Q       *big.Int
+				Comment string
+			}{
+				KeyType: ssh.KeyAlgoRSA,
+				N:       prv.N,
+				E:       big.NewInt(int64(prv.E)),
+				D:       prv.D,
+				Iqmp:    prv.Precomputed.Qinv,
+				P:       prv.Primes[0],
+				Q:       prv.Primes[1],
+				Comment: comment,
+			},
+		)
+	default:
+		return nil, fmt.Errorf("invalid key type %s, valid types are %v", keyType, Types())
+	}
+
+	signer, err := ssh.NewSignerFromKey(signerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer: %w", err)
+	}
+
+	privateKey, err := marshalPrivateKey(signer.PublicKey(), keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := ssh.MarshalAuthorizedKey(signer.PublicKey())
+	if len(comment) > 0 {
+		publicKey = []byte(strings.TrimSuffix(string(publicKey), "\n") + " " + comment + "\n")
+	}
+
+	return &KeyPair{
+		PrivateKey:  privateKey,
+		PublicKey:   publicKey,
+		Fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+	}, nil
+}
+
+// Validate parses an OpenSSH private key and returns
+// fingerprint of its public key
+func Validate(privateKey []byte) (string, error) {
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse ssh private key: %w", err)
+	}
+
+	return ssh.FingerprintSHA256(signer.PublicKey()), nil
+}
+
+// marshalPrivateKey encodes key data in openssh-key-v1 format
+// without encryption as described in OpenSSH PROTOCOL.key
+func marshalPrivateKey(pub ssh.PublicKey, keyData []byte) ([]byte, error) {
+	checkBytes := make([]byte, 4)
+	if _, err := rand.Read(checkBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate check bytes: %w", err)
+	}
+	check := binary.BigEndian.Uint32(checkBytes)
+
+	private := ssh.Marshal(
+		struct {
+			Check1 uint32
+			Check2 uint32
+			Rest   []byte `ssh:"rest"`
+		}{
+			Check1: check,
+			Check2: check,
+			Rest:   keyData,
+		},
+	)
+
+	// pad to block size using bytes 1, 2, 3...
+	for i := 1; len(private)%blockSize != 0; i++ {
+		private = append(private, byte(i))
+	}
+
+	data := ssh.Marshal(
+		struct {
+			CipherName   string
+			KdfName      string
+			KdfOpts      string
+			NumKeys      uint32
+			PubKey       []byte
+			PrivKeyBlock []byte
+		}{
+			CipherName:   "none",
+			KdfName:      "none",
+			KdfOpts:      "",
+			NumKeys:      1,
+			PubKey:       pub.Marshal(),
+			PrivKeyBlock: private,
+		},
+	)
+
+	return pem.EncodeToMemory(
+		&pem.Block{
+			Type:  pemType,
+			Bytes: append([]byte(magic), data...),
+		},
+	), nil
+}
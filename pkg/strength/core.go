@@ -0,0 +1,478 @@
+package strength
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/kubetrail/mksecret/pkg/generate"
+)
+
+const (
+	DefaultMinLength  = 8
+	DefaultMinEntropy = 40
+)
+
+const (
+	PatternDictionary = "dictionary"
+	PatternBanned     = "banned"
+	PatternSequence   = "sequence"
+	PatternRepeat     = "repeat"
+	PatternYear       = "year"
+	PatternBruteforce = "bruteforce"
+)
+
+const (
+	minMatchLen = 3
+	maxMatchLen = 24
+)
+
+// commonPasswords are ranked by popularity
+var commonPasswords = []string{
+	"password", "123456", "12345678", "qwerty", "123456789", "12345", "1234",
+	"111111", "1234567", "dragon", "123123", "baseball", "abc123", "football",
+	"monkey", "letmein", "696969", "shadow", "master", "666666", "qwertyuiop",
+	"123321", "mustang", "1234567890", "michael", "654321", "superman",
+	"1qaz2wsx", "7777777", "121212", "000000", "qazwsx", "123qwe", "killer",
+	"trustno1", "jordan", "jennifer", "zxcvbnm", "asdfgh", "hunter", "buster",
+	"soccer", "harley", "batman", "andrew", "tigger", "sunshine", "iloveyou",
+	"2000", "charlie", "robert", "thomas", "hockey", "ranger", "daniel",
+	"starwars", "klaster", "112233", "george", "computer", "michelle",
+	"jessica", "pepper", "1111", "zxcvbn", "555555", "11111111", "131313",
+	"freedom", "777777", "pass", "maggie", "159753", "aaaaaa", "ginger",
+	"princess", "joshua", "cheese", "amanda", "summer", "love", "ashley",
+	"nicole", "chelsea", "biteme", "matthew", "access", "yankees", "987654321",
+	"dallas", "austin", "thunder", "taylor", "matrix", "admin", "welcome",
+	"secret", "passw0rd", "login", "changeme", "default", "root", "toor",
+	"test", "guest", "hello", "whatever", "qwerty123", "password1", "mksecret",
+	"google", "secrets",
+}
+
+// keyboardRows are treated as sequences
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"abcdefghijklmnopqrstuvwxyz",
+	"01234567890",
+}
+
+var leet = map[rune][]rune{
+	'0': {'o'},
+	'1': {'i', 'l'},
+	'3': {'e'},
+	'4': {'a'},
+	'5': {'s'},
+	'7': {'t'},
+	'8': {'b'},
+	'@': {'a'},
+	'$': {'s'},
+	'!': {'i'},
+	'|': {'i', 'l'},
+}
+
+var dictionary = newDictionary()
+
+// newDictionary ranks common passwords by popularity followed by
+// BIP39 english words, which are equally likely as in diceware
+// passphrases
+func newDictionary() map[string]int {
+	d := make(map[string]int)
+	for i, word := range commonPasswords {
+		d[word] = i + 1
+	}
+
+	words := generate.Bip39Words()
+	for _, word := range words {
+		if _, ok := d[word]; !ok {
+			d[word] = len(words)
+		}
+	}
+
+	return d
+}
+
+// Match is a part of passphrase matching a guessable pattern
+type Match struct {
+	Token   string  `json:"token" yaml:"token"`
+	Pattern string  `json:"pattern" yaml:"pattern"`
+	Entropy float64 `json:"entropy" yaml:"entropy"`
+}
+
+// Result is an estimate of passphrase strength
+type Result struct {
+	Entropy float64 `json:"entropy" yaml:"entropy"`
+	Matches []Match `json:"matches,omitempty" yaml:"matches,omitempty"`
+}
+
+// Estimate estimates entropy of passphrase in bits as number of guesses
+// an attacker aware of common passwords, dictionary words, sequences,
+// repeats and leet substitutions would need. Passphrase is split into
+// the least entropic sequence of such patterns and characters guessed
+// by brute force. Banned words are treated as the most likely guesses.
+func Estimate(passphrase string, banned ...string) *Result {
+	runes := []rune(passphrase)
+	n := len(runes)
+	if n == 0 {
+		return &Result{}
+	}
+
+	bannedRanks := make(map[string]int)
+	for _, word := range banned {
+		bannedRanks[strings.ToLower(word)] = 1
+	}
+
+	bruteforce := math.Log2(float64(cardinality(runes)))
+
+	// best[i] is the least entropy of runes[:i] and last[i] is the
+	// match ending at i in that split
+	best := make([]float64, n+1)
+	last := make([]Match, n+1)
+	start := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(1)
+	}
+
+	for i := 0; i < n; i++ {
+		consider := func(j int, m Match) {
+			if e := best[i] + m.Entropy; e < best[j] {
+				best[j] = e
+				last[j] = m
+				start[j] = i
+			}
+		}
+
+		consider(i+1, Match{Token: string(runes[i]), Pattern: PatternBruteforce, Entropy: bruteforce})
+
+		for j := i + minMatchLen; j <= n && j-i <= maxMatchLen; j++ {
+			token := string(runes[i:j])
+			if entropy, ok := dictionaryEntropy(token, bannedRanks); ok {
+				consider(j, Match{Token: token, Pattern: PatternBanned, Entropy: entropy})
+			}
+			if entropy, ok := dictionaryEntropy(token, dictionary); ok {
+				consider(j, Match{Token: token, Pattern: PatternDictionary, Entropy: entropy})
+			}
+			if entropy, ok := sequenceEntropy(token); ok {
+				consider(j, Match{Token: token, Pattern: PatternSequence, Entropy: entropy})
+			}
+			if entropy, ok := repeatEntropy(runes[i:j], bruteforce); ok {
+				consider(j, Match{Token: token, Pattern: PatternRepeat, Entropy: entropy})
+			}
+			if entropy, ok := yearEntropy(token); ok {
+				consider(j, Match{Token: token, Pattern: PatternYear, Entropy: entropy})
+			}
+		}
+	}
+
+	result := &Result{Entropy: best[n]}
+	for j := n; j > 0; j = start[j] {
+		result.Matches = append([]Match{last[j]}, result.Matches...)
+	}
+
+	return result
+}
+
+// cardinality is the size of character pool passphrase draws from
+func cardinality(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, c := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.present {
+			pool += c.size
+		}
+	}
+
+	return pool
+}
+
+// dictionaryEntropy checks token against ranked words allowing for
+// capitalization, leet substitutions and reversal
+func dictionaryEntropy(token string, ranks map[string]int) (float64, bool) {
+	lower := strings.ToLower(token)
+	extra := capitalizationEntropy(token)
+
+	best := math.Inf(1)
+	for _, candidate := range unleet(lower) {
+		substituted := 0.0
+		for i, r := range []rune(lower) {
+			if []rune(candidate)[i] != r {
+				substituted++
+			}
+		}
+
+		if rank, ok := ranks[candidate]; ok {
+			best = math.Min(best, math.Log2(float64(rank))+extra+substituted)
+		}
+
+		if rank, ok := ranks[reverse(candidate)]; ok {
+			best = math.Min(best, math.Log2(float64(rank))+extra+substituted+1)
+		}
+	}
+
+	return best, !math.IsInf(best, 1)
+}
+
+// capitalizationEntropy is the entropy added by upper case letters,
+// which is low for common forms such as a capitalized first letter
+func capitalizationEntropy(token string) float64 {
+	runes := []rune(token)
+	var upper, lower int
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return 0
+	case lower == 0, upper == 1 && unicode.IsUpper(runes[0]):
+		return 1
+	}
+
+	combinations := 0.0
+	for k := 1; k <= upper && k <= lower; k++ {
+		combinations += binomial(upper+lower, k)
+	}
+
+	return math.Log2(combinations)
+}
+
+func binomial(n, k int) float64 {
+	result := 1.0
+	for i := 1; i <= k; i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+
+	return result
+}
+
+// unleet returns token with all combinations of leet substitutions
+// reverted, including token itself
+func unleet(token string) []string {
+	out := []string{""}
+	for _, r := range token {
+		subs, ok := leet[r]
+		if !ok {
+			for i := range out {
+				out[i] += string(r)
+			}
+			continue
+		}
+
+		next := make([]string, 0, len(out)*(len(subs)+1))
+		for _, prefix := range out {
+			next = append(next, prefix+string(r))
+			for _, sub := range subs {
+				next = append(next, prefix+string(sub))
+			}
+		}
+
+		// limit combinations for tokens with many substitutable characters
+		if len(next) > 64 {
+			next = next[:64]
+		}
+		out = next
+	}
+
+	return out
+}
+
+// containsWord checks if any part of passphrase of the length of word
+// matches it allowing for capitalization and leet substitutions. Parts
+// are checked separately since combinations of substitutions are
+// limited per token.
+func containsWord(passphrase, word string) bool {
+	runes := []rune(strings.ToLower(passphrase))
+	word = strings.ToLower(word)
+	n := len([]rune(word))
+	if n == 0 {
+		return false
+	}
+
+	for i := 0; i+n <= len(runes); i++ {
+		for _, candidate := range unleet(string(runes[i : i+n])) {
+			if candidate == word {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
+
+// sequenceEntropy checks if token is a run of consecutive characters,
+// such as abc or 987, or part of a keyboard row
+func sequenceEntropy(token string) (float64, bool) {
+	lower := strings.ToLower(token)
+	n := float64(len([]rune(lower)))
+
+	for _, row := range keyboardRows {
+		if strings.Contains(row, lower) {
+			return math.Log2(float64(len(keyboardRows)*len(row))) + math.Log2(n), true
+		}
+		if strings.Contains(row, reverse(lower)) {
+			return math.Log2(float64(len(keyboardRows)*len(row))) + math.Log2(n) + 1, true
+		}
+	}
+
+	runes := []rune(lower)
+	delta := runes[1] - runes[0]
+	if delta != 1 && delta != -1 {
+		return 0, false
+	}
+
+	for i := 2; i < len(runes); i++ {
+		if runes[i]-runes[i-1] != delta {
+			return 0, false
+		}
+	}
+
+	entropy := math.Log2(26) + math.Log2(n)
+	if delta < 0 {
+		entropy++
+	}
+
+	return entropy, true
+}
+
+// repeatEntropy checks if token repeats a single character
+func repeatEntropy(runes []rune, bruteforce float64) (float64, bool) {
+	for _, r := range runes[1:] {
+		if r != runes[0] {
+			return 0, false
+		}
+	}
+
+	return bruteforce + math.Log2(float64(len(runes))), true
+}
+
+// yearEntropy checks if token is a recent year
+func yearEntropy(token string) (float64, bool) {
+	var year int
+	if len(token) != 4 {
+		return 0, false
+	}
+
+	if _, err := fmt.Sscanf(token, "%d", &year); err != nil || year < 1900 || year > 2049 {
+		return 0, false
+	}
+
+	return math.Log2(150), true
+}
+
+// Policy declares requirements for new passphrases
+type Policy struct {
+	MinLength   int
+	MinEntropy  float64
+	BannedWords []string
+}
+
+// Check verifies passphrase against policy and explains why it is rejected.
+// Passphrase must not be the same as any of the values it protects.
+func (p *Policy) Check(passphrase string, values ...[]byte) error {
+	if n := len([]rune(passphrase)); n < p.MinLength {
+		return fmt.Errorf("passphrase is too short, it has %d characters and needs at least %d", n, p.MinLength)
+	}
+
+	for _, value := range values {
+		if bytes.Equal(bytes.TrimSpace(value), []byte(strings.TrimSpace(passphrase))) {
+			return fmt.Errorf("passphrase must not be the same as the secret value it encrypts")
+		}
+	}
+
+	for _, word := range p.BannedWords {
+		if containsWord(passphrase, word) {
+			return fmt.Errorf("passphrase contains banned word %q", word)
+		}
+	}
+
+	result := Estimate(passphrase, p.BannedWords...)
+
+	if result.Entropy < p.MinEntropy {
+		var reasons []string
+		for _, m := range result.Matches {
+			switch m.Pattern {
+			case PatternDictionary:
+				reasons = append(reasons, fmt.Sprintf("%q is a common word or password", m.Token))
+			case PatternSequence:
+				reasons = append(reasons, fmt.Sprintf("%q is a sequence", m.Token))
+			case PatternRepeat:
+				reasons = append(reasons, fmt.Sprintf("%q is a repeated character", m.Token))
+			case PatternYear:
+				reasons = append(reasons, fmt.Sprintf("%q is a year", m.Token))
+			}
+		}
+
+		explanation := "use a longer passphrase or more random characters"
+		if len(reasons) > 0 {
+			explanation = strings.Join(reasons, ", ") + ", " + explanation
+		}
+
+		return fmt.Errorf("passphrase is too weak, its estimated entropy is %.1f bits and needs to be at least %.0f bits: %s",
+			result.Entropy, p.MinEntropy, explanation)
+	}
+
+	return nil
+}
+
+// ReadWords reads a word list file with one word per line. Empty lines
+// and lines starting with # are ignored.
+func ReadWords(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open word list: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if len(word) == 0 || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read word list: %w", err)
+	}
+
+	return words, nil
+}
@@ -0,0 +1,88 @@
+package strength
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEstimatePatterns(t *testing.T) {
+	tests := []struct {
+		passphrase string
+		pattern    string
+	}{
+		{passphrase: "password", pattern: PatternDictionary},
+		{passphrase: "P@ssw0rd", pattern: PatternDictionary},
+		{passphrase: "drowssap", pattern: PatternDictionary},
+		{passphrase: "abcdef", pattern: PatternSequence},
+		{passphrase: "qwerty", pattern: PatternDictionary},
+		{passphrase: "asdfghjk", pattern: PatternSequence},
+		{passphrase: "zzzzzz", pattern: PatternRepeat},
+		{passphrase: "1987", pattern: PatternYear},
+	}
+
+	for _, tt := range tests {
+		result := Estimate(tt.passphrase)
+		if len(result.Matches) != 1 || result.Matches[0].Pattern != tt.pattern {
+			t.Errorf("Estimate(%q) matches = %+v, want single %s match", tt.passphrase, result.Matches, tt.pattern)
+		}
+	}
+}
+
+func TestEstimateOrdering(t *testing.T) {
+	weak := Estimate("Password1").Entropy
+	strong := Estimate("vX9#qL2@mN7$wR4!").Entropy
+	if weak >= strong {
+		t.Errorf("entropy of weak passphrase %.1f is not below strong one %.1f", weak, strong)
+	}
+
+	if e := Estimate("").Entropy; e != 0 {
+		t.Errorf("entropy of empty passphrase = %.1f, want 0", e)
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := &Policy{
+		MinLength:   DefaultMinLength,
+		MinEntropy:  DefaultMinEntropy,
+		BannedWords: []string{"acme"},
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		values     [][]byte
+		wantErr    bool
+	}{
+		{name: "strong", passphrase: "vX9#qL2@mN7$wR4!"},
+		{name: "too short", passphrase: "aB3$", wantErr: true},
+		{name: "common", passphrase: "password123", wantErr: true},
+		{name: "sequence", passphrase: "abcdefgh12345678", wantErr: true},
+		{name: "banned", passphrase: "vX9#Acme@mN7$wR4!", wantErr: true},
+		{name: "banned leet", passphrase: "vX9#4cm3@mN7$wR4!", wantErr: true},
+		{name: "same as value", passphrase: "vX9#qL2@mN7$wR4!", values: [][]byte{[]byte("vX9#qL2@mN7$wR4!\n")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := policy.Check(tt.passphrase, tt.values...); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Check(%q) error = %v, wantErr %v", tt.name, tt.passphrase, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadWords(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(filename, []byte("# banned\nacme\n\n  widget  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := ReadWords(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"acme", "widget"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("ReadWords() = %q, want %q", words, want)
+	}
+}
@@ -0,0 +1,138 @@
+package tlscerts
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+const DefaultValidFor = 365 * 24 * time.Hour
+
+// Options declares properties of a generated certificate. Hosts
+// can be DNS names or IP addresses.
+type Options struct {
+	CommonName string
+	Hosts      []string
+	ValidFor   time.Duration
+	IsCa       bool
+}
+
+// Parse parses a PEM bundle holding a certificate chain and a private
+// key, validates that the key matches leaf certificate and returns leaf
+// certificate and private key
+func Parse(bundle []byte) (*x509.Certificate, crypto.Signer, error) {
+	pair, err := tls.X509KeyPair(bundle, bundle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate and key: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", pair.PrivateKey)
+	}
+
+	return leaf, signer, nil
+}
+
+// New generates an ECDSA P-256 private key and a certificate that is
+// self-signed when ca is nil, else signed by the ca. Returned PEM bundle
+// holds the certificate followed by the ca certificate, if any, and
+// the private key.
+func New(opts *Options, ca *x509.Certificate, caKey crypto.Signer) ([]byte, error) {
+	if len(opts.CommonName) == 0 {
+		return nil, fmt.Errorf("common name is required")
+	}
+
+	validFor := opts.ValidFor
+	if validFor == 0 {
+		validFor = DefaultValidFor
+	}
+
+	prv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: opts.CommonName},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(validFor),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+
+	if opts.IsCa {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+
+	for _, host := range opts.Hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	parent, parentKey := template, crypto.Signer(prv)
+	if ca != nil {
+		if !ca.IsCA {
+			return nil, fmt.Errorf("certificate %s is not a ca", ca.Subject.CommonName)
+		}
+
+		if template.NotAfter.After(ca.NotAfter) {
+			template.NotAfter = ca.NotAfter
+		}
+
+		parent, parentKey = ca, caKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, prv.Public(), parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(prv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	bb := new(bytes.Buffer)
+	blocks := []*pem.Block{{Type: "CERTIFICATE", Bytes: der}}
+	if ca != nil {
+		blocks = append(blocks, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+	}
+	blocks = append(blocks, &pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+
+	for _, block := range blocks {
+		if err := pem.Encode(bb, block); err != nil {
+			return nil, fmt.Errorf("failed to encode pem: %w", err)
+		}
+	}
+
+	return bb.Bytes(), nil
+}